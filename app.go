@@ -9,6 +9,7 @@ import (
 	"elasticgaze/internal/database"
 	"elasticgaze/internal/models"
 	"elasticgaze/internal/repository"
+	"elasticgaze/internal/secrets"
 	"elasticgaze/internal/service"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -22,6 +23,9 @@ type App struct {
 	esService          *service.ElasticsearchService
 	monacoCacheService *service.MonacoCacheService
 	collectionsService *service.CollectionsService
+	healthMonitor      *service.HealthMonitor
+	alertService       *service.AlertService
+	historyService     *service.HistoryService
 }
 
 // NewApp creates a new App application struct
@@ -42,6 +46,9 @@ func (a *App) startup(ctx context.Context) {
 		os.Exit(1)
 	}
 
+	// Watch cluster health in the background; the frontend listens for change events
+	a.healthMonitor.Start(ctx)
+
 	// Logger is already initialized in main.go, just log that we're ready
 	runtime.LogInfo(ctx, "ElasticGaze application startup completed successfully")
 }
@@ -71,24 +78,85 @@ func (a *App) initDatabase() error {
 
 	a.db = db
 
+	// Load the master key used to encrypt stored credentials
+	masterKeys, err := secrets.LoadMasterKeys(elasticGazeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load master key: %w", err)
+	}
+	cipher, err := secrets.NewCipher(masterKeys.Current)
+	if err != nil {
+		return fmt.Errorf("failed to initialize secret encryption: %w", err)
+	}
+
 	// Initialize repository and service layers
-	configRepo := repository.NewConfigRepository(db.GetConnection())
-	a.configService = service.NewConfigService(configRepo)
+	configRepo := repository.NewConfigRepository(db.GetConnection(), cipher)
+
+	// Re-encrypt the credentials when the key source changed, then drop the old key
+	if masterKeys.Previous != nil {
+		previous, err := secrets.NewCipher(masterKeys.Previous)
+		if err != nil {
+			return fmt.Errorf("failed to initialize secret encryption: %w", err)
+		}
+		rekeyed, err := configRepo.Rekey(previous)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt stored credentials: %w", err)
+		}
+		if err := masterKeys.Retire(); err != nil {
+			return fmt.Errorf("failed to remove the previous master key: %w", err)
+		}
+		runtime.LogInfof(a.ctx, "Re-encrypted stored credentials for %d connection(s) with the new master key", rekeyed)
+	}
+
+	// Refuse to start with a key that cannot read the stored credentials
+	if err := configRepo.CheckMasterKey(); err != nil {
+		return err
+	}
+
+	// Encrypt any credentials left in plaintext by older versions
+	migrated, err := configRepo.EncryptExistingSecrets()
+	if err != nil {
+		return fmt.Errorf("failed to encrypt stored credentials: %w", err)
+	}
+	if migrated > 0 {
+		runtime.LogInfof(a.ctx, "Encrypted stored credentials for %d connection(s)", migrated)
+	}
+
+	settingsRepo := repository.NewSettingsRepository(db.GetConnection())
+	a.configService = service.NewConfigService(configRepo, settingsRepo)
 	a.esService = service.NewElasticsearchService()
 
 	// Initialize collections repository and service
 	collectionsRepo := repository.NewCollectionsRepository(db.GetConnection())
 	a.collectionsService = service.NewCollectionsService(collectionsRepo)
 
+	// Record every executed REST request in the request history
+	historyRepo := repository.NewHistoryRepository(db.GetConnection())
+	a.historyService = service.NewHistoryService(historyRepo, a.collectionsService)
+	a.esService.SetRequestRecorder(a.historyService.Record)
+
 	// Initialize Monaco cache service
 	a.monacoCacheService = service.NewMonacoCacheService(elasticGazeDir)
 
+	// Initialize the cluster health monitor
+	a.healthMonitor = service.NewHealthMonitor(a.configService, a.esService, settingsRepo, a.emitEvent)
+
+	// Evaluate alert rules after every health poll
+	alertRepo := repository.NewAlertRepository(db.GetConnection())
+	a.alertService = service.NewAlertService(alertRepo, a.configService, a.esService, a.emitEvent, service.DesktopNotify)
+	a.healthMonitor.OnCheck(a.alertService.Evaluate)
+
 	return nil
 }
 
 // Close closes the database connection
 func (a *App) Close() error {
 	runtime.LogInfo(a.ctx, "Closing application and database connection")
+	if a.healthMonitor != nil {
+		a.healthMonitor.Stop()
+	}
+	if a.esService != nil {
+		a.esService.Close()
+	}
 	if a.db != nil {
 		return a.db.Close()
 	}
@@ -111,50 +179,279 @@ func (a *App) CreateConfig(req *models.CreateConfigRequest) (*models.Config, err
 		return nil, err
 	}
 	runtime.LogInfof(a.ctx, "Successfully created configuration with ID: %d", config.ID)
-	return config, nil
+	return config.Redacted(), nil
 }
 
-// GetConfigByID retrieves a configuration by ID
+// GetConfigByID retrieves a configuration by ID with its secrets redacted
 func (a *App) GetConfigByID(id int) (*models.Config, error) {
+	config, err := a.configService.GetConfigByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return config.Redacted(), nil
+}
+
+// RevealConfig retrieves a configuration by ID including its decrypted secrets
+func (a *App) RevealConfig(id int) (*models.Config, error) {
+	runtime.LogInfof(a.ctx, "Revealing secrets for configuration ID: %d", id)
 	return a.configService.GetConfigByID(id)
 }
 
-// GetAllConfigs retrieves all configurations
-func (a *App) GetAllConfigs() ([]*models.Config, error) {
-	return a.configService.GetAllConfigs()
+// GetAllConfigs retrieves the configurations matching the filter with their secrets
+// redacted. A nil filter returns every configuration, newest first.
+func (a *App) GetAllConfigs(filter *models.ConfigFilter) ([]*models.Config, error) {
+	configs, err := a.configService.GetAllConfigs(filter)
+	if err != nil {
+		return nil, err
+	}
+	return redactConfigs(configs), nil
 }
 
-// GetDefaultConfig retrieves the default configuration
+// GetDefaultConfig retrieves the default configuration with its secrets redacted
 func (a *App) GetDefaultConfig() (*models.Config, error) {
-	return a.configService.GetDefaultConfig()
+	config, err := a.configService.GetDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	return config.Redacted(), nil
 }
 
 // UpdateConfig updates an existing configuration
 func (a *App) UpdateConfig(id int, req *models.UpdateConfigRequest) (*models.Config, error) {
-	return a.configService.UpdateConfig(id, req)
+	config, err := a.configService.UpdateConfig(id, req)
+	if err != nil {
+		return nil, err
+	}
+	return config.Redacted(), nil
+}
+
+// redactConfigs redacts the secrets of every config in the list
+func redactConfigs(configs []*models.Config) []*models.Config {
+	redacted := make([]*models.Config, len(configs))
+	for i, config := range configs {
+		redacted[i] = config.Redacted()
+	}
+	return redacted
 }
 
 // DeleteConfig deletes a configuration by ID
 func (a *App) DeleteConfig(id int) error {
-	return a.configService.DeleteConfig(id)
+	if err := a.configService.DeleteConfig(id); err != nil {
+		return err
+	}
+	if err := a.alertService.DeleteConfigAlerts(id); err != nil {
+		runtime.LogWarningf(a.ctx, "Failed to delete alert rules of configuration %d: %v", id, err)
+	}
+	return nil
+}
+
+// SetDefaultConfig makes a configuration the default, replacing the current default
+func (a *App) SetDefaultConfig(id int) (*models.Config, error) {
+	runtime.LogInfof(a.ctx, "Setting default configuration ID: %d", id)
+	config, err := a.configService.SetDefaultConfig(id)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to set default configuration: %v", err)
+		return nil, err
+	}
+	return config.Redacted(), nil
+}
+
+// GetDefaultConnectionSettings returns what happens when the default connection is deleted
+func (a *App) GetDefaultConnectionSettings() (*models.DefaultConnectionSettings, error) {
+	return a.configService.GetDefaultConnectionSettings()
+}
+
+// UpdateDefaultConnectionSettings changes what happens when the default connection is deleted
+func (a *App) UpdateDefaultConnectionSettings(settings *models.DefaultConnectionSettings) (*models.DefaultConnectionSettings, error) {
+	runtime.LogInfof(a.ctx, "Updating default connection policy: %s", settings.OnDefaultDeleted)
+	return a.configService.UpdateDefaultConnectionSettings(settings)
+}
+
+// GetAllConfigTags returns every tag used by a connection, for tag filters and suggestions
+func (a *App) GetAllConfigTags() ([]string, error) {
+	return a.configService.GetAllConfigTags()
+}
+
+// Connection Groups API Methods
+
+// CreateConfigGroup creates a new connection group
+func (a *App) CreateConfigGroup(req *models.CreateConfigGroupRequest) (*models.ConfigGroup, error) {
+	runtime.LogInfof(a.ctx, "Creating connection group: %s", req.Name)
+	group, err := a.configService.CreateConfigGroup(req)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to create connection group: %v", err)
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetAllConfigGroups retrieves every connection group
+func (a *App) GetAllConfigGroups() ([]*models.ConfigGroup, error) {
+	return a.configService.GetAllConfigGroups()
+}
+
+// UpdateConfigGroup renames a connection group or moves it under another group
+func (a *App) UpdateConfigGroup(id int, req *models.UpdateConfigGroupRequest) (*models.ConfigGroup, error) {
+	runtime.LogInfof(a.ctx, "Updating connection group ID: %d", id)
+	group, err := a.configService.UpdateConfigGroup(id, req)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to update connection group: %v", err)
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteConfigGroup deletes a connection group; its connections and subgroups move to its parent
+func (a *App) DeleteConfigGroup(id int) error {
+	runtime.LogInfof(a.ctx, "Deleting connection group ID: %d", id)
+	return a.configService.DeleteConfigGroup(id)
+}
+
+// ExportConfigs exports connections to a portable JSON or YAML document
+func (a *App) ExportConfigs(req *models.ExportConfigsRequest) (string, error) {
+	runtime.LogInfof(a.ctx, "Exporting %d configuration(s) (0 means all)", len(req.ConfigIDs))
+	return a.configService.ExportConfigs(req)
+}
+
+// ExportConfigsToFile exports connections to a file chosen in a save dialog.
+// It returns the path written, or an empty string when the dialog was cancelled.
+func (a *App) ExportConfigsToFile(req *models.ExportConfigsRequest) (string, error) {
+	extension := models.ExportFormatJSON
+	if req.Format == models.ExportFormatYAML {
+		extension = "yaml"
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Connections",
+		DefaultFilename: "elasticgaze-connections." + extension,
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Connection exports (*.json, *.yaml, *.yml)", Pattern: "*.json;*.yaml;*.yml"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	data, err := a.configService.ExportConfigs(req)
+	if err != nil {
+		return "", err
+	}
+	// Exports may contain secrets, so keep them private to the user
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to write export file: %v", err)
+		return "", fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	runtime.LogInfof(a.ctx, "Exported configurations to %s", path)
+	return path, nil
+}
+
+// ImportConfigs imports connections from an export document
+func (a *App) ImportConfigs(req *models.ImportConfigsRequest) (*models.ImportConfigsResult, error) {
+	runtime.LogInfof(a.ctx, "Importing configurations (conflict policy: %s)", req.ConflictPolicy)
+	result, err := a.configService.ImportConfigs(req)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to import configurations: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadImportFile reads an export document chosen in an open dialog, to pass to ImportConfigs.
+// It returns an empty string when the dialog was cancelled.
+func (a *App) ReadImportFile() (string, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Connections",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Connection exports (*.json, *.yaml, *.yml)", Pattern: "*.json;*.yaml;*.yml"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read import file: %w", err)
+	}
+	return string(data), nil
 }
 
 // TestConnection tests an Elasticsearch connection
 func (a *App) TestConnection(req *models.TestConnectionRequest) (*models.TestConnectionResponse, error) {
 	runtime.LogInfof(a.ctx, "Testing Elasticsearch connection to %s:%s", req.Host, req.Port)
-	response, err := a.esService.TestConnection(req)
+
+	// Forms editing a saved connection only hold redacted secrets
+	if req.ConfigID != nil {
+		stored, err := a.configService.GetConfigByID(*req.ConfigID)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.FillRedactedSecrets(stored); err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+	}
+
+	ctx, done, err := a.esService.StartExecution(a.ctx, "", "Connection test")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	response, err := a.esService.TestConnection(ctx, req)
 	if err != nil {
 		runtime.LogErrorf(a.ctx, "Connection test failed: %v", err)
 		return response, err
 	}
 	if response.Success {
 		runtime.LogInfo(a.ctx, "Connection test successful")
+		if req.ConfigID != nil {
+			a.recordClusterProfile(*req.ConfigID, response.Profile)
+		}
 	} else {
 		runtime.LogWarningf(a.ctx, "Connection test failed: %s", response.Message)
 	}
 	return response, nil
 }
 
+// DiagnoseConnection checks an Elasticsearch connection stage by stage (DNS, TCP, TLS,
+// HTTP, authentication and cluster info) and reports the outcome of each
+func (a *App) DiagnoseConnection(req *models.TestConnectionRequest) (*models.ConnectionDiagnostics, error) {
+	runtime.LogInfof(a.ctx, "Diagnosing Elasticsearch connection to %s:%s", req.Host, req.Port)
+
+	// Forms editing a saved connection only hold redacted secrets
+	if req.ConfigID != nil {
+		stored, err := a.configService.GetConfigByID(*req.ConfigID)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.FillRedactedSecrets(stored); err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+	}
+
+	ctx, done, err := a.esService.StartExecution(a.ctx, "", "Connection diagnosis")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	diagnostics, err := a.esService.DiagnoseConnection(ctx, req)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Connection diagnosis failed: %v", err)
+		return diagnostics, err
+	}
+	if diagnostics.Success {
+		runtime.LogInfo(a.ctx, "Connection diagnosis passed")
+		if req.ConfigID != nil {
+			a.recordClusterProfile(*req.ConfigID, diagnostics.Profile)
+		}
+	} else {
+		runtime.LogWarningf(a.ctx, "Connection diagnosis failed: %s", diagnostics.Message)
+	}
+	return diagnostics, nil
+}
+
 // HasDefaultConfig checks if there is a default connection configured
 func (a *App) HasDefaultConfig() (bool, error) {
 	return a.configService.HasDefaultConfig()
@@ -174,17 +471,30 @@ func (a *App) TestDefaultConnection() (*models.TestConnectionResponse, error) {
 	}
 
 	// Convert config to test request
-	testReq := &models.TestConnectionRequest{
-		Host:                 defaultConfig.Host,
-		Port:                 defaultConfig.Port,
-		SSLOrHTTPS:           defaultConfig.SSLOrHTTPS,
-		AuthenticationMethod: defaultConfig.AuthenticationMethod,
-		Username:             defaultConfig.Username,
-		Password:             defaultConfig.Password,
+	testReq := defaultConfig.ToConnectionRequest()
+
+	ctx, done, err := a.esService.StartExecution(a.ctx, "", "Connection test", defaultConfig)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
 	// Test the connection
-	return a.esService.TestConnection(testReq)
+	response, err := a.esService.TestConnection(ctx, testReq)
+	if err == nil && response.Success {
+		a.recordClusterProfile(defaultConfig.ID, response.Profile)
+	}
+	return response, err
+}
+
+// recordClusterProfile stores the distribution and version detected for a saved connection
+func (a *App) recordClusterProfile(configID int, profile *models.ClusterProfile) {
+	if profile == nil {
+		return
+	}
+	if err := a.configService.UpdateClusterProfile(configID, profile); err != nil {
+		runtime.LogWarningf(a.ctx, "Failed to store cluster profile for config %d: %v", configID, err)
+	}
 }
 
 // GetClusterDashboardData retrieves dashboard data for the default cluster
@@ -195,14 +505,25 @@ func (a *App) GetClusterDashboardData() (*models.ProcessedDashboardData, error)
 		return nil, fmt.Errorf("no default connection configured: %w", err)
 	}
 
+	ctx, done, err := a.esService.StartExecution(a.ctx, "", "Cluster dashboard", defaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	// Fetch cluster dashboard data
-	return a.esService.GetClusterDashboardData(defaultConfig)
+	data, err := a.esService.GetClusterDashboardData(ctx, defaultConfig)
+	a.recordConfigUsage(defaultConfig.ID)
+	if err == nil {
+		a.recordClusterProfile(defaultConfig.ID, data.Profile)
+	}
+	return data, err
 }
 
 // GetClusterDashboardDataByConfig retrieves dashboard data for a specific cluster configuration
 func (a *App) GetClusterDashboardDataByConfig(configID int) (*models.ProcessedDashboardData, error) {
 	// Get all configs to find the one with the specified ID
-	configs, err := a.configService.GetAllConfigs()
+	configs, err := a.configService.GetAllConfigs(nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get configurations: %w", err)
 	}
@@ -219,57 +540,332 @@ func (a *App) GetClusterDashboardDataByConfig(configID int) (*models.ProcessedDa
 		return nil, fmt.Errorf("configuration with ID %d not found", configID)
 	}
 
+	ctx, done, err := a.esService.StartExecution(a.ctx, "", "Cluster dashboard", selectedConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	// Fetch cluster dashboard data
-	return a.esService.GetClusterDashboardData(selectedConfig)
+	data, err := a.esService.GetClusterDashboardData(ctx, selectedConfig)
+	a.recordConfigUsage(selectedConfig.ID)
+	if err == nil {
+		a.recordClusterProfile(selectedConfig.ID, data.Profile)
+	}
+	return data, err
+}
+
+// GetLifecyclePolicies returns the ILM (Elasticsearch) or ISM (OpenSearch) policies of a connection as JSON
+func (a *App) GetLifecyclePolicies(configID int) (string, error) {
+	config, err := a.configService.GetConfigByID(configID)
+	if err != nil {
+		return "", err
+	}
+	ctx, done, err := a.esService.StartExecution(a.ctx, "", "Lifecycle policies", config)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	return a.esService.GetLifecyclePolicies(ctx, config)
 }
 
-// GetClusterHealthForAllConfigs retrieves cluster health for all configurations
+// GetAuthenticatedUser returns the user a connection authenticates as, as JSON
+func (a *App) GetAuthenticatedUser(configID int) (string, error) {
+	config, err := a.configService.GetConfigByID(configID)
+	if err != nil {
+		return "", err
+	}
+	ctx, done, err := a.esService.StartExecution(a.ctx, "", "Authenticated user", config)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	return a.esService.GetAuthenticatedUser(ctx, config)
+}
+
+// GetClusterHealthForAllConfigs checks every configuration now and returns each
+// connection's status by name. Connections that cannot be reached are reported as
+// "unreachable" rather than "red".
 func (a *App) GetClusterHealthForAllConfigs() (map[string]string, error) {
-	configs, err := a.configService.GetAllConfigs()
+	statuses, err := a.healthMonitor.CheckAll(a.ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get configurations: %w", err)
+		return nil, err
 	}
 
 	healthMap := make(map[string]string)
+	for _, status := range statuses {
+		healthMap[status.ConnectionName] = status.Status
+	}
 
-	for _, config := range configs {
-		// Convert config to test request
-		testReq := &models.TestConnectionRequest{
-			Host:                 config.Host,
-			Port:                 config.Port,
-			SSLOrHTTPS:           config.SSLOrHTTPS,
-			AuthenticationMethod: config.AuthenticationMethod,
-			Username:             config.Username,
-			Password:             config.Password,
+	return healthMap, nil
+}
+
+// GetClusterHealthStatuses returns the latest background health check result of every connection
+func (a *App) GetClusterHealthStatuses() []*models.ClusterHealthStatus {
+	return a.healthMonitor.Statuses()
+}
+
+// RefreshClusterHealth checks every connection now instead of waiting for the next poll
+func (a *App) RefreshClusterHealth() ([]*models.ClusterHealthStatus, error) {
+	runtime.LogInfo(a.ctx, "Refreshing cluster health for all connections")
+	statuses, err := a.healthMonitor.CheckAll(a.ctx)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to refresh cluster health: %v", err)
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// GetHealthMonitorSettings returns the background health monitor settings
+func (a *App) GetHealthMonitorSettings() models.HealthMonitorSettings {
+	return a.healthMonitor.Settings()
+}
+
+// UpdateHealthMonitorSettings changes the background health monitor interval, timeout and concurrency
+func (a *App) UpdateHealthMonitorSettings(settings models.HealthMonitorSettings) (models.HealthMonitorSettings, error) {
+	runtime.LogInfof(a.ctx, "Updating health monitor settings: enabled=%t interval=%ds timeout=%ds", settings.Enabled, settings.IntervalSeconds, settings.TimeoutSeconds)
+	updated, err := a.healthMonitor.UpdateSettings(settings)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to update health monitor settings: %v", err)
+		return models.HealthMonitorSettings{}, err
+	}
+	return updated, nil
+}
+
+// Alert API Methods
+
+// CreateAlertRule creates an alert rule evaluated on every health check of a connection
+func (a *App) CreateAlertRule(req *models.CreateAlertRuleRequest) (*models.AlertRule, error) {
+	runtime.LogInfof(a.ctx, "Creating %s alert rule for configuration ID: %d", req.RuleType, req.ConfigID)
+	rule, err := a.alertService.CreateAlertRule(req)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to create alert rule: %v", err)
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetAlertRules returns the alert rules of a connection, or of every connection when configID is nil
+func (a *App) GetAlertRules(configID *int) ([]*models.AlertRule, error) {
+	return a.alertService.GetAlertRules(configID)
+}
+
+// UpdateAlertRule updates an alert rule
+func (a *App) UpdateAlertRule(id int, req *models.UpdateAlertRuleRequest) (*models.AlertRule, error) {
+	runtime.LogInfof(a.ctx, "Updating alert rule ID: %d", id)
+	rule, err := a.alertService.UpdateAlertRule(id, req)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to update alert rule: %v", err)
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteAlertRule deletes an alert rule and its alerts
+func (a *App) DeleteAlertRule(id int) error {
+	runtime.LogInfof(a.ctx, "Deleting alert rule ID: %d", id)
+	return a.alertService.DeleteAlertRule(id)
+}
+
+// GetAlerts returns triggered alerts, newest first
+func (a *App) GetAlerts(filter *models.AlertFilter) ([]*models.Alert, error) {
+	return a.alertService.GetAlerts(filter)
+}
+
+// AcknowledgeAlert marks an alert as seen; it stays open until its condition clears
+func (a *App) AcknowledgeAlert(id int) (*models.Alert, error) {
+	runtime.LogInfof(a.ctx, "Acknowledging alert ID: %d", id)
+	return a.alertService.AcknowledgeAlert(id)
+}
+
+// ResolveAlert closes an alert by hand
+func (a *App) ResolveAlert(id int) (*models.Alert, error) {
+	runtime.LogInfof(a.ctx, "Resolving alert ID: %d", id)
+	return a.alertService.ResolveAlert(id)
+}
+
+// emitEvent publishes a Wails event to the frontend
+func (a *App) emitEvent(name string, data ...interface{}) {
+	runtime.EventsEmit(a.ctx, name, data...)
+}
+
+// ExecuteElasticsearchRequest executes a generic REST request on the connection selected
+// by req.ConfigID, or on the default connection when none is selected
+func (a *App) ExecuteElasticsearchRequest(req *models.ElasticsearchRestRequest) (*models.ElasticsearchRestResponse, error) {
+	if req.ConfigID == nil {
+		// Get default config
+		defaultConfig, err := a.configService.GetDefaultConfig()
+		if err != nil {
+			return &models.ElasticsearchRestResponse{
+				Success:      false,
+				StatusCode:   500,
+				ErrorDetails: "No default connection configured",
+				ErrorCode:    "NO_DEFAULT_CONNECTION",
+			}, nil
+		}
+
+		return a.executeRestRequest(defaultConfig, req)
+	}
+
+	config, err := a.configService.GetConfigByID(*req.ConfigID)
+	if err != nil {
+		return connectionNotFoundResponse(*req.ConfigID, err), nil
+	}
+	return a.executeRestRequest(config, req)
+}
+
+// executeRestRequest runs a REST request on one connection as a cancellable execution
+func (a *App) executeRestRequest(config *models.Config, req *models.ElasticsearchRestRequest) (*models.ElasticsearchRestResponse, error) {
+	ctx, done, err := a.esService.StartExecution(a.ctx, req.ExecutionID, req.Method+" "+req.Endpoint, config)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	resp, err := a.esService.ExecuteRestRequest(ctx, config, req)
+	a.recordConfigUsage(config.ID)
+	return resp, err
+}
+
+// ExecuteElasticsearchRequestOnConnections runs the same REST request on every connection
+// in req.ConfigIDs concurrently, e.g. to compare a mapping across dev, staging and prod.
+// It returns one response per connection, in the order of the IDs.
+func (a *App) ExecuteElasticsearchRequestOnConnections(req *models.ElasticsearchRestRequest) ([]*models.ElasticsearchRestResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if len(req.ConfigIDs) == 0 {
+		resp, err := a.ExecuteElasticsearchRequest(req)
+		if err != nil {
+			return nil, err
 		}
+		return []*models.ElasticsearchRestResponse{resp}, nil
+	}
+
+	runtime.LogInfof(a.ctx, "Running %s %s on %d connections", req.Method, req.Endpoint, len(req.ConfigIDs))
 
-		// Get cluster health
-		health, err := a.esService.GetClusterHealthByConfig(testReq)
+	results := make([]*models.ElasticsearchRestResponse, 0, len(req.ConfigIDs))
+	var configs []*models.Config
+	var positions []int
+	seen := make(map[int]bool, len(req.ConfigIDs))
+	for _, id := range req.ConfigIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		config, err := a.configService.GetConfigByID(id)
 		if err != nil {
-			healthMap[config.ConnectionName] = "red" // Default to red on error
-		} else {
-			healthMap[config.ConnectionName] = health.Status
+			results = append(results, connectionNotFoundResponse(id, err))
+			continue
 		}
+		configs = append(configs, config)
+		positions = append(positions, len(results))
+		results = append(results, nil)
 	}
 
-	return healthMap, nil
+	ctx, done, err := a.esService.StartExecution(a.ctx, req.ExecutionID, req.Method+" "+req.Endpoint, configs...)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	for i, resp := range a.esService.ExecuteRestRequestOnConfigs(ctx, configs, req) {
+		results[positions[i]] = resp
+		a.recordConfigUsage(configs[i].ID)
+	}
+	return results, nil
 }
 
-// ExecuteElasticsearchRequest executes a generic REST request to the default Elasticsearch cluster
-func (a *App) ExecuteElasticsearchRequest(req *models.ElasticsearchRestRequest) (*models.ElasticsearchRestResponse, error) {
-	// Get default config
-	defaultConfig, err := a.configService.GetDefaultConfig()
+// NewExecutionID returns an ID to pass as execution_id on a REST request, so that the
+// request can be cancelled with CancelRequest while it runs
+func (a *App) NewExecutionID() (string, error) {
+	return service.NewExecutionID()
+}
+
+// CancelRequest aborts a running request. The HTTP call is abandoned straight away and
+// the tasks it started on the cluster are cancelled in the background when possible.
+func (a *App) CancelRequest(executionID string) error {
+	runtime.LogInfof(a.ctx, "Cancelling request %s", executionID)
+	if err := a.esService.CancelExecution(executionID); err != nil {
+		runtime.LogWarningf(a.ctx, "Failed to cancel request %s: %v", executionID, err)
+		return err
+	}
+	return nil
+}
+
+// GetRunningRequests lists the cancellable requests in flight, oldest first
+func (a *App) GetRunningRequests() []models.RunningExecution {
+	return a.esService.RunningExecutions()
+}
+
+// connectionNotFoundResponse reports a REST request on a connection that could not be loaded
+func connectionNotFoundResponse(configID int, err error) *models.ElasticsearchRestResponse {
+	return &models.ElasticsearchRestResponse{
+		Success:      false,
+		StatusCode:   404,
+		ErrorDetails: err.Error(),
+		ErrorCode:    "CONNECTION_NOT_FOUND",
+		ConfigID:     configID,
+	}
+}
+
+// recordConfigUsage stores when a connection last executed a request, for sorting by recent use
+func (a *App) recordConfigUsage(configID int) {
+	if err := a.configService.RecordConfigUsage(configID); err != nil {
+		runtime.LogWarningf(a.ctx, "Failed to record usage of configuration %d: %v", configID, err)
+	}
+}
+
+// Request History API Methods
+
+// GetRequestHistory returns recorded REST requests matching the filter, newest first
+func (a *App) GetRequestHistory(filter *models.HistoryFilter) ([]*models.HistoryEntry, error) {
+	return a.historyService.GetHistory(filter)
+}
+
+// GetRequestHistoryEntry returns a single recorded REST request
+func (a *App) GetRequestHistoryEntry(id int) (*models.HistoryEntry, error) {
+	return a.historyService.GetHistoryEntry(id)
+}
+
+// ReplayRequestHistoryEntry runs a recorded REST request again on the same connection
+func (a *App) ReplayRequestHistoryEntry(id int) (*models.ElasticsearchRestResponse, error) {
+	runtime.LogInfof(a.ctx, "Replaying request history entry ID: %d", id)
+	req, err := a.historyService.ReplayRequest(id)
 	if err != nil {
-		return &models.ElasticsearchRestResponse{
-			Success:      false,
-			StatusCode:   500,
-			ErrorDetails: "No default connection configured",
-			ErrorCode:    "NO_DEFAULT_CONNECTION",
-		}, nil
+		return nil, err
 	}
+	return a.ExecuteElasticsearchRequest(req)
+}
+
+// PinRequestHistoryEntry pins or unpins a recorded request; pinned requests are never pruned
+func (a *App) PinRequestHistoryEntry(id int, pinned bool) (*models.HistoryEntry, error) {
+	return a.historyService.PinHistoryEntry(id, pinned)
+}
+
+// DeleteRequestHistoryEntry removes a recorded request
+func (a *App) DeleteRequestHistoryEntry(id int) error {
+	return a.historyService.DeleteHistoryEntry(id)
+}
+
+// SaveRequestHistoryToCollection saves a recorded request into a collection
+func (a *App) SaveRequestHistoryToCollection(req *models.SaveHistoryRequest) (*models.Request, error) {
+	runtime.LogInfof(a.ctx, "Saving request history entry %d to collection %d", req.HistoryID, req.CollectionID)
+	request, err := a.historyService.SaveToCollection(req)
+	if err != nil {
+		runtime.LogErrorf(a.ctx, "Failed to save request history entry: %v", err)
+		return nil, err
+	}
+	return request, nil
+}
 
-	// Execute the request
-	return a.esService.ExecuteRestRequest(defaultConfig, req)
+// PruneRequestHistory removes unpinned recorded requests by age, count or size and
+// returns how many were removed
+func (a *App) PruneRequestHistory(req *models.PruneHistoryRequest) (int, error) {
+	return a.historyService.PruneHistory(req)
 }
 
 // Monaco Cache API Methods
@@ -56,7 +56,7 @@ func exampleUsage(app *App) {
 
 	// Example 3: Get all configurations
 	fmt.Println("\n3. Retrieving all configurations...")
-	configs, err := app.GetAllConfigs()
+	configs, err := app.GetAllConfigs(nil)
 	if err != nil {
 		log.Printf("Error getting all configs: %v", err)
 		return
@@ -118,7 +118,7 @@ func exampleUsage(app *App) {
 
 	// Verify deletion
 	fmt.Println("\n8. Verifying deletion - getting all configs again...")
-	finalConfigs, err := app.GetAllConfigs()
+	finalConfigs, err := app.GetAllConfigs(nil)
 	if err != nil {
 		log.Printf("Error getting final configs: %v", err)
 		return
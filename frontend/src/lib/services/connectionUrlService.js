@@ -23,7 +23,7 @@ export class ConnectionUrlService {
 	/**
 	 * Builds the base URL from connection configuration
 	 * @param {Object} config - Connection configuration object
-	 * @returns {string} Base URL (e.g., "https://localhost:9200")
+	 * @returns {string} Base URL (e.g., "https://localhost:9200" or "https://proxy.local/es")
 	 */
 	static buildBaseUrl(config) {
 		if (!config || !config.host) {
@@ -35,7 +35,9 @@ export class ConnectionUrlService {
 			? `:${config.port}` 
 			: '';
 		
-		return `${protocol}://${config.host}${port}`;
+		const prefix = (config.path_prefix || '').trim().replace(/^\/+|\/+$/g, '');
+
+		return `${protocol}://${config.host}${port}${prefix ? `/${prefix}` : ''}`;
 	}
 
 	/**
@@ -7,6 +7,7 @@ import { writable } from 'svelte/store';
  * @property {string} host - Elasticsearch host address
  * @property {number} port - Elasticsearch port number
  * @property {boolean} useSSL - Whether to use SSL/HTTPS
+ * @property {boolean} verifyTLS - Whether to verify the server certificate over SSL/HTTPS
  * @property {('basic'|'apikey'|'none')} authType - Authentication type
  * @property {string} username - Username for basic auth
  * @property {string} password - Password for basic auth
@@ -200,6 +201,7 @@ export const connectionService = {
 			username: '',
 			password: '',
 			useSSL: false,
+			verifyTLS: true,
 			apiKey: '',
 			authType: 'basic',
 			isDefault: false,
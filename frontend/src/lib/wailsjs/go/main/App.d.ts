@@ -69,6 +69,8 @@ export function InvalidateMonacoCache(arg1:string):Promise<void>;
 
 export function ReadMonacoCache(arg1:string):Promise<string>;
 
+export function SetDefaultConfig(arg1:number):Promise<models.Config>;
+
 export function TestConnection(arg1:models.TestConnectionRequest):Promise<models.TestConnectionResponse>;
 
 export function TestDefaultConnection():Promise<models.TestConnectionResponse>;
@@ -134,6 +134,10 @@ export function ReadMonacoCache(arg1) {
   return window['go']['main']['App']['ReadMonacoCache'](arg1);
 }
 
+export function SetDefaultConfig(arg1) {
+  return window['go']['main']['App']['SetDefaultConfig'](arg1);
+}
+
 export function TestConnection(arg1) {
   return window['go']['main']['App']['TestConnection'](arg1);
 }
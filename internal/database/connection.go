@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"elasticgaze/internal/logging"
@@ -55,6 +56,12 @@ func NewConnection(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	// Bring tables created by older versions up to date
+	if err := db.migrateSchema(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return db, nil
 }
 
@@ -175,11 +182,116 @@ func (db *DB) initializeSchema() error {
 		return fmt.Errorf("failed to create tbl_requests table: %w", err)
 	}
 
-	// Create trigger to update updated_at field for tbl_config
+	// Create connection groups table
+	configGroupsQuery := `
+	CREATE TABLE IF NOT EXISTS tbl_config_groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL,
+		parent_group_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (parent_group_id) REFERENCES tbl_config_groups(id) ON DELETE CASCADE
+	);`
+
+	if _, err := db.conn.Exec(configGroupsQuery); err != nil {
+		return fmt.Errorf("failed to create tbl_config_groups table: %w", err)
+	}
+
+	// Create alert rules and triggered alerts tables
+	alertRulesQuery := `
+	CREATE TABLE IF NOT EXISTS tbl_alert_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		config_id INTEGER NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		rule_type VARCHAR(50) NOT NULL,
+		threshold REAL,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		notify BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (config_id) REFERENCES tbl_config(id) ON DELETE CASCADE
+	);`
+
+	if _, err := db.conn.Exec(alertRulesQuery); err != nil {
+		return fmt.Errorf("failed to create tbl_alert_rules table: %w", err)
+	}
+
+	alertsQuery := `
+	CREATE TABLE IF NOT EXISTS tbl_alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id INTEGER NOT NULL,
+		config_id INTEGER NOT NULL,
+		rule_name VARCHAR(255) NOT NULL,
+		rule_type VARCHAR(50) NOT NULL,
+		state VARCHAR(20) NOT NULL DEFAULT 'triggered',
+		message TEXT NOT NULL,
+		value REAL,
+		triggered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		acknowledged_at DATETIME,
+		resolved_at DATETIME,
+		suppresses_rule BOOLEAN NOT NULL DEFAULT 0,
+		FOREIGN KEY (rule_id) REFERENCES tbl_alert_rules(id) ON DELETE CASCADE,
+		FOREIGN KEY (config_id) REFERENCES tbl_config(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_alerts_state ON tbl_alerts(state, triggered_at);`
+
+	if _, err := db.conn.Exec(alertsQuery); err != nil {
+		return fmt.Errorf("failed to create tbl_alerts table: %w", err)
+	}
+
+	// Create request history table. Entries outlive their connection, so the name at the
+	// time of the request is kept alongside the ID.
+	historyQuery := `
+	CREATE TABLE IF NOT EXISTS tbl_request_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		config_id INTEGER NOT NULL,
+		connection_name VARCHAR(255) NOT NULL,
+		method VARCHAR(10) NOT NULL,
+		url TEXT NOT NULL,
+		headers TEXT,
+		body TEXT,
+		status_code INTEGER NOT NULL,
+		success BOOLEAN NOT NULL,
+		error_code VARCHAR(50),
+		error_details TEXT,
+		duration_ms INTEGER NOT NULL,
+		response_size INTEGER NOT NULL,
+		response TEXT NOT NULL,
+		response_truncated BOOLEAN NOT NULL DEFAULT 0,
+		pinned BOOLEAN NOT NULL DEFAULT 0,
+		executed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_request_history_executed_at ON tbl_request_history(executed_at);
+	CREATE INDEX IF NOT EXISTS idx_request_history_config ON tbl_request_history(config_id, executed_at);`
+
+	if _, err := db.conn.Exec(historyQuery); err != nil {
+		return fmt.Errorf("failed to create tbl_request_history table: %w", err)
+	}
+
+	// Create application settings table; values are JSON documents keyed by setting name
+	settingsQuery := `
+	CREATE TABLE IF NOT EXISTS tbl_app_settings (
+		key VARCHAR(100) PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := db.conn.Exec(settingsQuery); err != nil {
+		return fmt.Errorf("failed to create tbl_app_settings table: %w", err)
+	}
+
+	// Create trigger to update updated_at field for tbl_config. Recording that a
+	// connection was used is not an edit, so it leaves updated_at alone; the trigger
+	// is recreated because older versions created it without that condition.
+	if _, err := db.conn.Exec(`DROP TRIGGER IF EXISTS update_tbl_config_updated_at;`); err != nil {
+		return fmt.Errorf("failed to drop config trigger: %w", err)
+	}
+
 	configTriggerQuery := `
-	CREATE TRIGGER IF NOT EXISTS update_tbl_config_updated_at 
+	CREATE TRIGGER update_tbl_config_updated_at 
 	AFTER UPDATE ON tbl_config
 	FOR EACH ROW
+	WHEN NEW.last_used_at IS OLD.last_used_at
 	BEGIN
 		UPDATE tbl_config SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
 	END;`
@@ -188,6 +300,32 @@ func (db *DB) initializeSchema() error {
 		return fmt.Errorf("failed to create config trigger: %w", err)
 	}
 
+	// Create trigger to update updated_at field for tbl_config_groups
+	configGroupsTriggerQuery := `
+	CREATE TRIGGER IF NOT EXISTS update_tbl_config_groups_updated_at 
+	AFTER UPDATE ON tbl_config_groups
+	FOR EACH ROW
+	BEGIN
+		UPDATE tbl_config_groups SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+	END;`
+
+	if _, err := db.conn.Exec(configGroupsTriggerQuery); err != nil {
+		return fmt.Errorf("failed to create config groups trigger: %w", err)
+	}
+
+	// Create trigger to update updated_at field for tbl_alert_rules
+	alertRulesTriggerQuery := `
+	CREATE TRIGGER IF NOT EXISTS update_tbl_alert_rules_updated_at 
+	AFTER UPDATE ON tbl_alert_rules
+	FOR EACH ROW
+	BEGIN
+		UPDATE tbl_alert_rules SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+	END;`
+
+	if _, err := db.conn.Exec(alertRulesTriggerQuery); err != nil {
+		return fmt.Errorf("failed to create alert rules trigger: %w", err)
+	}
+
 	// Create trigger to update updated_at field for tbl_collections
 	collectionsTriggerQuery := `
 	CREATE TRIGGER IF NOT EXISTS update_tbl_collections_updated_at 
@@ -230,6 +368,113 @@ func (db *DB) initializeSchema() error {
 	return nil
 }
 
+// columnMigration describes a column that was added to a table after its initial release
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// configColumnMigrations lists the tbl_config columns added after the initial schema
+var configColumnMigrations = []columnMigration{
+	{table: "tbl_config", column: "api_key", definition: "TEXT"},
+	{table: "tbl_config", column: "bearer_token", definition: "TEXT"},
+	{table: "tbl_config", column: "tls_verify", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{table: "tbl_config", column: "tls_ca_cert", definition: "TEXT"},
+	{table: "tbl_config", column: "tls_ca_fingerprint", definition: "VARCHAR(128)"},
+	{table: "tbl_config", column: "tls_client_cert", definition: "TEXT"},
+	{table: "tbl_config", column: "tls_client_key", definition: "TEXT"},
+	{table: "tbl_config", column: "nodes", definition: "TEXT"},
+	{table: "tbl_config", column: "sniff_nodes", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{table: "tbl_config", column: "cloud_id", definition: "TEXT"},
+	{table: "tbl_config", column: "proxy_url", definition: "TEXT"},
+	{table: "tbl_config", column: "proxy_username", definition: "TEXT"},
+	{table: "tbl_config", column: "proxy_password", definition: "TEXT"},
+	{table: "tbl_config", column: "no_proxy", definition: "TEXT"},
+	{table: "tbl_config", column: "ssh_host", definition: "TEXT"},
+	{table: "tbl_config", column: "ssh_user", definition: "TEXT"},
+	{table: "tbl_config", column: "ssh_key_file", definition: "TEXT"},
+	{table: "tbl_config", column: "ssh_key_passphrase", definition: "TEXT"},
+	{table: "tbl_config", column: "ssh_password", definition: "TEXT"},
+	{table: "tbl_config", column: "ssh_known_hosts_file", definition: "TEXT"},
+	{table: "tbl_config", column: "ssh_ignore_host_key", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{table: "tbl_config", column: "path_prefix", definition: "TEXT"},
+	{table: "tbl_config", column: "default_headers", definition: "TEXT"},
+	{table: "tbl_config", column: "aws_region", definition: "VARCHAR(50)"},
+	{table: "tbl_config", column: "aws_service", definition: "VARCHAR(10)"},
+	{table: "tbl_config", column: "aws_access_key_id", definition: "VARCHAR(128)"},
+	{table: "tbl_config", column: "aws_secret_access_key", definition: "TEXT"},
+	{table: "tbl_config", column: "aws_session_token", definition: "TEXT"},
+	{table: "tbl_config", column: "aws_profile", definition: "VARCHAR(128)"},
+	{table: "tbl_config", column: "safety_mode", definition: "VARCHAR(30) NOT NULL DEFAULT 'unrestricted'"},
+	{table: "tbl_config", column: "connect_timeout_seconds", definition: "INTEGER NOT NULL DEFAULT 10"},
+	{table: "tbl_config", column: "tls_timeout_seconds", definition: "INTEGER NOT NULL DEFAULT 10"},
+	{table: "tbl_config", column: "response_timeout_seconds", definition: "INTEGER NOT NULL DEFAULT 60"},
+	{table: "tbl_config", column: "max_retries", definition: "INTEGER NOT NULL DEFAULT 3"},
+	{table: "tbl_config", column: "distribution", definition: "VARCHAR(30)"},
+	{table: "tbl_config", column: "cluster_version", definition: "VARCHAR(30)"},
+	{table: "tbl_config", column: "build_flavor", definition: "VARCHAR(30)"},
+	{table: "tbl_config", column: "profile_detected_at", definition: "DATETIME"},
+	{table: "tbl_config", column: "group_id", definition: "INTEGER REFERENCES tbl_config_groups(id) ON DELETE SET NULL"},
+	{table: "tbl_config", column: "tags", definition: "TEXT"},
+	{table: "tbl_config", column: "favorite", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{table: "tbl_config", column: "last_used_at", definition: "DATETIME"},
+}
+
+// alertColumnMigrations lists the tbl_alerts columns added after the table was introduced
+var alertColumnMigrations = []columnMigration{
+	{table: "tbl_alerts", column: "suppresses_rule", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+}
+
+// migrateSchema adds columns that are missing from tables created by older versions
+func (db *DB) migrateSchema() error {
+	for _, migration := range slices.Concat(configColumnMigrations, alertColumnMigrations) {
+		exists, err := db.columnExists(migration.table, migration.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", migration.table, migration.column, migration.definition)
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", migration.table, migration.column, err)
+		}
+		logging.Infof("Added column %s.%s", migration.table, migration.column)
+	}
+
+	return nil
+}
+
+// columnExists checks whether a column is present on a table
+func (db *DB) columnExists(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read table info for %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, fmt.Errorf("failed to scan table info for %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
 // ExecuteWithRetry executes a function with retry logic for database busy errors
 func (db *DB) ExecuteWithRetry(operation func() error, maxRetries int) error {
 	var lastErr error
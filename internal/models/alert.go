@@ -0,0 +1,124 @@
+package models
+
+// Alert rule types
+const (
+	AlertRuleStatusRed        = "status_red"        // Cluster status is red
+	AlertRuleUnreachable      = "unreachable"       // Cluster cannot be reached
+	AlertRuleUnassignedShards = "unassigned_shards" // More than Threshold unassigned shards
+	AlertRuleDiskWatermark    = "disk_watermark"    // A node's disk usage is at or above Threshold percent
+	AlertRuleNodeCountBelow   = "node_count_below"  // Fewer than Threshold nodes in the cluster
+)
+
+// DefaultDiskWatermarkPercent matches Elasticsearch's default low disk watermark
+const DefaultDiskWatermarkPercent = 85
+
+// Alert states
+const (
+	AlertStateTriggered    = "triggered"
+	AlertStateAcknowledged = "acknowledged" // Seen by the user but the condition still holds
+	AlertStateResolved     = "resolved"
+)
+
+// Wails events emitted with an Alert when an alert is raised or resolved
+const (
+	AlertTriggeredEvent = "alert:triggered"
+	AlertResolvedEvent  = "alert:resolved"
+)
+
+// AlertRule is a local condition checked against a connection's health on every
+// health monitor poll
+type AlertRule struct {
+	ID        int      `json:"id" db:"id"`
+	ConfigID  int      `json:"config_id" db:"config_id"`
+	Name      string   `json:"name" db:"name"`
+	RuleType  string   `json:"rule_type" db:"rule_type"`
+	Threshold *float64 `json:"threshold,omitempty" db:"threshold"`
+	Enabled   bool     `json:"enabled" db:"enabled"`
+	Notify    bool     `json:"notify" db:"notify"` // Show a desktop notification when the rule triggers
+	CreatedAt string   `json:"created_at" db:"created_at"`
+	UpdatedAt string   `json:"updated_at" db:"updated_at"`
+}
+
+// CreateAlertRuleRequest represents the request payload for creating an alert rule
+type CreateAlertRuleRequest struct {
+	ConfigID  int      `json:"config_id" validate:"required"`
+	Name      string   `json:"name"` // Defaults to a description of the rule
+	RuleType  string   `json:"rule_type" validate:"required"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	Enabled   *bool    `json:"enabled,omitempty"` // Defaults to true
+	Notify    *bool    `json:"notify,omitempty"`  // Defaults to true
+}
+
+// UpdateAlertRuleRequest represents the request payload for updating an alert rule
+type UpdateAlertRuleRequest struct {
+	Name      *string  `json:"name,omitempty"`
+	RuleType  *string  `json:"rule_type,omitempty"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	Enabled   *bool    `json:"enabled,omitempty"`
+	Notify    *bool    `json:"notify,omitempty"`
+}
+
+// Validate checks the rule type and threshold, applying the default disk watermark
+func (r *CreateAlertRuleRequest) Validate() error {
+	if r.ConfigID <= 0 {
+		return ErrAlertRuleConfigRequired
+	}
+	if r.RuleType == AlertRuleDiskWatermark && r.Threshold == nil {
+		threshold := float64(DefaultDiskWatermarkPercent)
+		r.Threshold = &threshold
+	}
+	return ValidateAlertThreshold(r.RuleType, r.Threshold)
+}
+
+// ValidateAlertThreshold checks that a rule type is known and has a sensible threshold
+func ValidateAlertThreshold(ruleType string, threshold *float64) error {
+	switch ruleType {
+	case AlertRuleStatusRed, AlertRuleUnreachable:
+		return nil
+	case AlertRuleUnassignedShards:
+		if threshold == nil || *threshold < 0 {
+			return ErrInvalidAlertThreshold
+		}
+	case AlertRuleDiskWatermark:
+		if threshold == nil || *threshold <= 0 || *threshold > 100 {
+			return ErrInvalidAlertThreshold
+		}
+	case AlertRuleNodeCountBelow:
+		if threshold == nil || *threshold < 1 {
+			return ErrInvalidAlertThreshold
+		}
+	default:
+		return ErrInvalidAlertRuleType
+	}
+	return nil
+}
+
+// Alert is a triggered alert rule
+type Alert struct {
+	ID             int      `json:"id" db:"id"`
+	RuleID         int      `json:"rule_id" db:"rule_id"`
+	ConfigID       int      `json:"config_id" db:"config_id"`
+	ConnectionName string   `json:"connection_name" db:"connection_name"`
+	RuleName       string   `json:"rule_name" db:"rule_name"`
+	RuleType       string   `json:"rule_type" db:"rule_type"`
+	State          string   `json:"state" db:"state"`
+	Message        string   `json:"message" db:"message"`
+	Value          *float64 `json:"value,omitempty" db:"value"` // Measured value that breached the rule
+	TriggeredAt    string   `json:"triggered_at" db:"triggered_at"`
+	AcknowledgedAt *string  `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	ResolvedAt     *string  `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// AlertFilter narrows the alert list. A nil filter lists the most recent alerts.
+type AlertFilter struct {
+	ConfigID *int     `json:"config_id,omitempty"`
+	States   []string `json:"states,omitempty"` // e.g. ["triggered", "acknowledged"] for open alerts
+	Limit    int      `json:"limit,omitempty"`  // Defaults to 200
+}
+
+// Alert validation errors
+var (
+	ErrAlertRuleConfigRequired = &ValidationError{Field: "config_id", Message: "connection is required"}
+	ErrInvalidAlertRuleType    = &ValidationError{Field: "rule_type", Message: "rule type must be \"status_red\", \"unreachable\", \"unassigned_shards\", \"disk_watermark\" or \"node_count_below\""}
+	ErrInvalidAlertThreshold   = &ValidationError{Field: "threshold", Message: "threshold is missing or out of range for this rule type"}
+)
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"strconv"
+	"strings"
+)
+
 // ClusterInfo represents cluster information response
 type ClusterInfo struct {
 	Name        string `json:"name"`
@@ -7,6 +12,7 @@ type ClusterInfo struct {
 	ClusterUUID string `json:"cluster_uuid"`
 	Version     struct {
 		Number                           string `json:"number"`
+		Distribution                     string `json:"distribution"` // Only reported by OpenSearch
 		BuildFlavor                      string `json:"build_flavor"`
 		BuildType                        string `json:"build_type"`
 		BuildHash                        string `json:"build_hash"`
@@ -18,6 +24,86 @@ type ClusterInfo struct {
 	Tagline string `json:"tagline"`
 }
 
+// Distributions recognized by ClusterProfile
+const (
+	DistributionElasticsearch = "elasticsearch"
+	DistributionOpenSearch    = "opensearch"
+)
+
+// ClusterProfile describes the detected distribution, version and build flavor of a
+// cluster, so requests can be adapted to what the cluster supports
+type ClusterProfile struct {
+	Distribution string `json:"distribution"`
+	Version      string `json:"version"`
+	MajorVersion int    `json:"major_version"`
+	BuildFlavor  string `json:"build_flavor,omitempty"` // "default", "oss" or "serverless" on Elasticsearch
+}
+
+// NewClusterProfile detects the cluster profile from the root endpoint response
+func NewClusterProfile(info *ClusterInfo) *ClusterProfile {
+	distribution := DistributionElasticsearch
+	if strings.EqualFold(info.Version.Distribution, DistributionOpenSearch) {
+		distribution = DistributionOpenSearch
+	}
+	return NewClusterProfileFromParts(distribution, info.Version.Number, info.Version.BuildFlavor)
+}
+
+// NewClusterProfileFromParts rebuilds a profile from its stored fields
+func NewClusterProfileFromParts(distribution, version, buildFlavor string) *ClusterProfile {
+	major, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return &ClusterProfile{
+		Distribution: distribution,
+		Version:      version,
+		MajorVersion: major,
+		BuildFlavor:  buildFlavor,
+	}
+}
+
+// IsOpenSearch reports whether the cluster runs OpenSearch
+func (p *ClusterProfile) IsOpenSearch() bool {
+	return p != nil && p.Distribution == DistributionOpenSearch
+}
+
+// IsServerless reports whether the cluster is an Elastic Cloud Serverless project
+func (p *ClusterProfile) IsServerless() bool {
+	return p != nil && p.BuildFlavor == "serverless"
+}
+
+// SupportsNodesInfo reports whether the _nodes APIs are available; serverless projects hide them
+func (p *ClusterProfile) SupportsNodesInfo() bool {
+	return !p.IsServerless()
+}
+
+// SupportsClusterHealth reports whether _cluster/health is available; serverless projects hide it
+func (p *ClusterProfile) SupportsClusterHealth() bool {
+	return !p.IsServerless()
+}
+
+// AuthenticatePath returns the endpoint describing the authenticated user
+func (p *ClusterProfile) AuthenticatePath() string {
+	switch {
+	case p.IsOpenSearch():
+		return "/_plugins/_security/authinfo"
+	case p != nil && p.MajorVersion > 0 && p.MajorVersion < 7:
+		return "/_xpack/security/_authenticate"
+	}
+	return "/_security/_authenticate"
+}
+
+// LifecyclePoliciesPath returns the endpoint listing index lifecycle policies: ILM on
+// Elasticsearch, ISM on OpenSearch. ok is false when the cluster has no such API.
+func (p *ClusterProfile) LifecyclePoliciesPath() (path string, ok bool) {
+	switch {
+	case p.IsOpenSearch():
+		return "/_plugins/_ism/policies", true
+	case p.IsServerless():
+		return "", false
+	case p != nil && p.MajorVersion > 0 && p.MajorVersion < 6:
+		return "", false
+	}
+	return "/_ilm/policy", true
+}
+
 // ClusterHealth represents cluster health response
 type ClusterHealth struct {
 	ClusterName                 string  `json:"cluster_name"`
@@ -118,9 +204,11 @@ type IndexMetrics struct {
 
 // ProcessedDashboardData represents processed data for the frontend
 type ProcessedDashboardData struct {
-	ClusterInfo   *ClusterInfo   `json:"cluster_info"`
-	ClusterHealth *ClusterHealth `json:"cluster_health"`
-	NodeCounts    *NodeCounts    `json:"node_counts"`
-	ShardCounts   *ShardCounts   `json:"shard_counts"`
-	IndexMetrics  *IndexMetrics  `json:"index_metrics"`
+	ClusterInfo   *ClusterInfo    `json:"cluster_info"`
+	ClusterHealth *ClusterHealth  `json:"cluster_health"`
+	NodeCounts    *NodeCounts     `json:"node_counts"`
+	ShardCounts   *ShardCounts    `json:"shard_counts"`
+	IndexMetrics  *IndexMetrics   `json:"index_metrics"`
+	Profile       *ClusterProfile `json:"profile,omitempty"`
+	Warnings      []string        `json:"warnings,omitempty"` // Sections skipped or unavailable on this cluster
 }
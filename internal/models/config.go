@@ -1,45 +1,172 @@
 package models
 
+import (
+	"net/url"
+	"strings"
+)
+
 // Config represents an Elasticsearch connection configuration
 type Config struct {
-	ID                   int     `json:"id" db:"id"`
-	ConnectionName       string  `json:"connection_name" db:"connection_name"`
-	EnvIndicatorColor    string  `json:"env_indicator_color" db:"env_indicator_color"`
-	Host                 string  `json:"host" db:"host"`
-	Port                 string  `json:"port" db:"port"`
-	SSLOrHTTPS           bool    `json:"ssl_or_https" db:"ssl_or_https"`
-	AuthenticationMethod string  `json:"authentication_method" db:"authentication_method"`
-	Username             *string `json:"username,omitempty" db:"username"`
-	Password             *string `json:"password,omitempty" db:"password"`
-	SetAsDefault         bool    `json:"set_as_default" db:"set_as_default"`
-	CreatedAt            string  `json:"created_at" db:"created_at"`
-	UpdatedAt            string  `json:"updated_at" db:"updated_at"`
+	ID                     int          `json:"id" db:"id"`
+	ConnectionName         string       `json:"connection_name" db:"connection_name"`
+	EnvIndicatorColor      string       `json:"env_indicator_color" db:"env_indicator_color"`
+	Host                   string       `json:"host" db:"host"`
+	Port                   string       `json:"port" db:"port"`
+	CloudID                *string      `json:"cloud_id,omitempty" db:"cloud_id"` // Elastic Cloud ID; when set, Host/Port/SSLOrHTTPS are derived from it
+	SSLOrHTTPS             bool         `json:"ssl_or_https" db:"ssl_or_https"`
+	AuthenticationMethod   string       `json:"authentication_method" db:"authentication_method"`
+	Username               *string      `json:"username,omitempty" db:"username"`
+	Password               *string      `json:"password,omitempty" db:"password"`
+	APIKey                 *string      `json:"api_key,omitempty" db:"api_key"`
+	BearerToken            *string      `json:"bearer_token,omitempty" db:"bearer_token"`
+	TLSVerify              bool         `json:"tls_verify" db:"tls_verify"`
+	TLSCACert              *string      `json:"tls_ca_cert,omitempty" db:"tls_ca_cert"`               // CA bundle: file path or inline PEM
+	TLSCAFingerprint       *string      `json:"tls_ca_fingerprint,omitempty" db:"tls_ca_fingerprint"` // SHA-256 fingerprint of a certificate in the server chain
+	TLSClientCert          *string      `json:"tls_client_cert,omitempty" db:"tls_client_cert"`       // Client certificate for mTLS: file path or inline PEM
+	TLSClientKey           *string      `json:"tls_client_key,omitempty" db:"tls_client_key"`         // Client private key for mTLS: file path or inline PEM
+	Nodes                  []string     `json:"nodes,omitempty" db:"nodes"`                           // Additional node endpoints ("host:port" or URL), tried after Host/Port
+	SniffNodes             bool         `json:"sniff_nodes" db:"sniff_nodes"`                         // Discover further nodes through /_nodes/http
+	ProxyURL               *string      `json:"proxy_url,omitempty" db:"proxy_url"`                   // http://, https:// or socks5:// proxy for this connection
+	ProxyUsername          *string      `json:"proxy_username,omitempty" db:"proxy_username"`
+	ProxyPassword          *string      `json:"proxy_password,omitempty" db:"proxy_password"`
+	NoProxy                []string     `json:"no_proxy,omitempty" db:"no_proxy"` // Hosts, domains (".example.com") or CIDR ranges that bypass the proxy
+	SSHHost                *string      `json:"ssh_host,omitempty" db:"ssh_host"` // Bastion "host" or "host:port"; when set, traffic goes through an SSH tunnel
+	SSHUser                *string      `json:"ssh_user,omitempty" db:"ssh_user"`
+	SSHKeyFile             *string      `json:"ssh_key_file,omitempty" db:"ssh_key_file"` // Private key: file path or inline PEM
+	SSHKeyPassphrase       *string      `json:"ssh_key_passphrase,omitempty" db:"ssh_key_passphrase"`
+	SSHPassword            *string      `json:"ssh_password,omitempty" db:"ssh_password"`
+	SSHKnownHostsFile      *string      `json:"ssh_known_hosts_file,omitempty" db:"ssh_known_hosts_file"` // Defaults to ~/.ssh/known_hosts
+	SSHIgnoreHostKey       bool         `json:"ssh_ignore_host_key" db:"ssh_ignore_host_key"`
+	PathPrefix             *string      `json:"path_prefix,omitempty" db:"path_prefix"`         // Base path when the cluster sits behind a reverse proxy, e.g. "/es"
+	DefaultHeaders         []HTTPHeader `json:"default_headers,omitempty" db:"default_headers"` // Sent with every request to this connection
+	AWSRegion              *string      `json:"aws_region,omitempty" db:"aws_region"`
+	AWSService             *string      `json:"aws_service,omitempty" db:"aws_service"`             // "es" (default) or "aoss" for OpenSearch Serverless
+	AWSAccessKeyID         *string      `json:"aws_access_key_id,omitempty" db:"aws_access_key_id"` // Static keys; when empty, AWSProfile or the AWS_* env vars are used
+	AWSSecretAccessKey     *string      `json:"aws_secret_access_key,omitempty" db:"aws_secret_access_key"`
+	AWSSessionToken        *string      `json:"aws_session_token,omitempty" db:"aws_session_token"`
+	AWSProfile             *string      `json:"aws_profile,omitempty" db:"aws_profile"`                 // Named profile in ~/.aws/credentials
+	SafetyMode             string       `json:"safety_mode" db:"safety_mode"`                           // "read-only", "confirm-destructive" or "unrestricted"
+	ConnectTimeoutSeconds  int          `json:"connect_timeout_seconds" db:"connect_timeout_seconds"`   // Limit for establishing the TCP connection
+	TLSTimeoutSeconds      int          `json:"tls_timeout_seconds" db:"tls_timeout_seconds"`           // Limit for the TLS handshake
+	ResponseTimeoutSeconds int          `json:"response_timeout_seconds" db:"response_timeout_seconds"` // Limit for a whole request, including reading the response
+	MaxRetries             int          `json:"max_retries" db:"max_retries"`                           // Retries of idempotent requests on 429, 502, 503 and 504
+	GroupID                *int         `json:"group_id,omitempty" db:"group_id"`                       // Group in tbl_config_groups; nil when ungrouped
+	Tags                   []string     `json:"tags,omitempty" db:"tags"`                               // Free-form labels such as environment, team or region
+	Favorite               bool         `json:"favorite" db:"favorite"`
+	LastUsedAt             *string      `json:"last_used_at,omitempty" db:"last_used_at"`               // When the connection last executed a request
+	Distribution           *string      `json:"distribution,omitempty" db:"distribution"`               // Detected on connect: "elasticsearch" or "opensearch"
+	ClusterVersion         *string      `json:"cluster_version,omitempty" db:"cluster_version"`         // Detected on connect
+	BuildFlavor            *string      `json:"build_flavor,omitempty" db:"build_flavor"`               // Detected on connect
+	ProfileDetectedAt      *string      `json:"profile_detected_at,omitempty" db:"profile_detected_at"` // When the profile was first detected or last changed
+	SetAsDefault           bool         `json:"set_as_default" db:"set_as_default"`
+	CreatedAt              string       `json:"created_at" db:"created_at"`
+	UpdatedAt              string       `json:"updated_at" db:"updated_at"`
 }
 
 // CreateConfigRequest represents the request payload for creating a new config
 type CreateConfigRequest struct {
-	ConnectionName       string  `json:"connection_name" validate:"required"`
-	EnvIndicatorColor    string  `json:"env_indicator_color"`
-	Host                 string  `json:"host" validate:"required"`
-	Port                 string  `json:"port"`
-	SSLOrHTTPS           bool    `json:"ssl_or_https"`
-	AuthenticationMethod string  `json:"authentication_method"`
-	Username             *string `json:"username,omitempty"`
-	Password             *string `json:"password,omitempty"`
-	SetAsDefault         bool    `json:"set_as_default"`
+	ConnectionName         string       `json:"connection_name" validate:"required"`
+	EnvIndicatorColor      string       `json:"env_indicator_color"`
+	Host                   string       `json:"host" validate:"required"`
+	Port                   string       `json:"port"`
+	CloudID                *string      `json:"cloud_id,omitempty"`
+	SSLOrHTTPS             bool         `json:"ssl_or_https"`
+	AuthenticationMethod   string       `json:"authentication_method"`
+	Username               *string      `json:"username,omitempty"`
+	Password               *string      `json:"password,omitempty"`
+	APIKey                 *string      `json:"api_key,omitempty"`
+	BearerToken            *string      `json:"bearer_token,omitempty"`
+	TLSVerify              *bool        `json:"tls_verify,omitempty"` // Defaults to true
+	TLSCACert              *string      `json:"tls_ca_cert,omitempty"`
+	TLSCAFingerprint       *string      `json:"tls_ca_fingerprint,omitempty"`
+	TLSClientCert          *string      `json:"tls_client_cert,omitempty"`
+	TLSClientKey           *string      `json:"tls_client_key,omitempty"`
+	Nodes                  []string     `json:"nodes,omitempty"`
+	SniffNodes             bool         `json:"sniff_nodes"`
+	ProxyURL               *string      `json:"proxy_url,omitempty"`
+	ProxyUsername          *string      `json:"proxy_username,omitempty"`
+	ProxyPassword          *string      `json:"proxy_password,omitempty"`
+	NoProxy                []string     `json:"no_proxy,omitempty"`
+	SSHHost                *string      `json:"ssh_host,omitempty"`
+	SSHUser                *string      `json:"ssh_user,omitempty"`
+	SSHKeyFile             *string      `json:"ssh_key_file,omitempty"`
+	SSHKeyPassphrase       *string      `json:"ssh_key_passphrase,omitempty"`
+	SSHPassword            *string      `json:"ssh_password,omitempty"`
+	SSHKnownHostsFile      *string      `json:"ssh_known_hosts_file,omitempty"`
+	SSHIgnoreHostKey       bool         `json:"ssh_ignore_host_key"`
+	PathPrefix             *string      `json:"path_prefix,omitempty"`
+	DefaultHeaders         []HTTPHeader `json:"default_headers,omitempty"`
+	AWSRegion              *string      `json:"aws_region,omitempty"`
+	AWSService             *string      `json:"aws_service,omitempty"`
+	AWSAccessKeyID         *string      `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey     *string      `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken        *string      `json:"aws_session_token,omitempty"`
+	AWSProfile             *string      `json:"aws_profile,omitempty"`
+	SafetyMode             string       `json:"safety_mode,omitempty"`              // Defaults to "unrestricted"
+	ConnectTimeoutSeconds  *int         `json:"connect_timeout_seconds,omitempty"`  // Defaults to DefaultConnectTimeoutSeconds
+	TLSTimeoutSeconds      *int         `json:"tls_timeout_seconds,omitempty"`      // Defaults to DefaultTLSTimeoutSeconds
+	ResponseTimeoutSeconds *int         `json:"response_timeout_seconds,omitempty"` // Defaults to DefaultResponseTimeoutSeconds
+	MaxRetries             *int         `json:"max_retries,omitempty"`              // Defaults to DefaultMaxRetries; 0 turns retries off
+	GroupID                *int         `json:"group_id,omitempty"`
+	Tags                   []string     `json:"tags,omitempty"`
+	Favorite               bool         `json:"favorite"`
+	SetAsDefault           bool         `json:"set_as_default"`
+}
+
+// VerifiesTLS reports whether the new connection checks server certificates; it does
+// unless turned off
+func (c *CreateConfigRequest) VerifiesTLS() bool {
+	return c.TLSVerify == nil || *c.TLSVerify
 }
 
 // UpdateConfigRequest represents the request payload for updating an existing config
 type UpdateConfigRequest struct {
-	ConnectionName       *string `json:"connection_name,omitempty"`
-	EnvIndicatorColor    *string `json:"env_indicator_color,omitempty"`
-	Host                 *string `json:"host,omitempty"`
-	Port                 *string `json:"port,omitempty"`
-	SSLOrHTTPS           *bool   `json:"ssl_or_https,omitempty"`
-	AuthenticationMethod *string `json:"authentication_method,omitempty"`
-	Username             *string `json:"username,omitempty"`
-	Password             *string `json:"password,omitempty"`
-	SetAsDefault         *bool   `json:"set_as_default,omitempty"`
+	ConnectionName         *string      `json:"connection_name,omitempty"`
+	EnvIndicatorColor      *string      `json:"env_indicator_color,omitempty"`
+	Host                   *string      `json:"host,omitempty"`
+	Port                   *string      `json:"port,omitempty"`
+	CloudID                *string      `json:"cloud_id,omitempty"` // An empty string removes the Cloud ID
+	SSLOrHTTPS             *bool        `json:"ssl_or_https,omitempty"`
+	AuthenticationMethod   *string      `json:"authentication_method,omitempty"`
+	Username               *string      `json:"username,omitempty"`
+	Password               *string      `json:"password,omitempty"`
+	APIKey                 *string      `json:"api_key,omitempty"`
+	BearerToken            *string      `json:"bearer_token,omitempty"`
+	TLSVerify              *bool        `json:"tls_verify,omitempty"`
+	TLSCACert              *string      `json:"tls_ca_cert,omitempty"`
+	TLSCAFingerprint       *string      `json:"tls_ca_fingerprint,omitempty"`
+	TLSClientCert          *string      `json:"tls_client_cert,omitempty"`
+	TLSClientKey           *string      `json:"tls_client_key,omitempty"`
+	Nodes                  []string     `json:"nodes,omitempty"` // nil leaves the nodes unchanged, an empty list clears them
+	SniffNodes             *bool        `json:"sniff_nodes,omitempty"`
+	ProxyURL               *string      `json:"proxy_url,omitempty"` // An empty string removes the proxy
+	ProxyUsername          *string      `json:"proxy_username,omitempty"`
+	ProxyPassword          *string      `json:"proxy_password,omitempty"`
+	NoProxy                []string     `json:"no_proxy,omitempty"` // nil leaves the list unchanged, an empty list clears it
+	SSHHost                *string      `json:"ssh_host,omitempty"` // An empty string turns the tunnel off
+	SSHUser                *string      `json:"ssh_user,omitempty"`
+	SSHKeyFile             *string      `json:"ssh_key_file,omitempty"`
+	SSHKeyPassphrase       *string      `json:"ssh_key_passphrase,omitempty"`
+	SSHPassword            *string      `json:"ssh_password,omitempty"`
+	SSHKnownHostsFile      *string      `json:"ssh_known_hosts_file,omitempty"`
+	SSHIgnoreHostKey       *bool        `json:"ssh_ignore_host_key,omitempty"`
+	PathPrefix             *string      `json:"path_prefix,omitempty"`
+	DefaultHeaders         []HTTPHeader `json:"default_headers,omitempty"` // nil leaves the headers unchanged, an empty list clears them
+	AWSRegion              *string      `json:"aws_region,omitempty"`
+	AWSService             *string      `json:"aws_service,omitempty"`
+	AWSAccessKeyID         *string      `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey     *string      `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken        *string      `json:"aws_session_token,omitempty"`
+	AWSProfile             *string      `json:"aws_profile,omitempty"`
+	SafetyMode             *string      `json:"safety_mode,omitempty"`
+	ConnectTimeoutSeconds  *int         `json:"connect_timeout_seconds,omitempty"`
+	TLSTimeoutSeconds      *int         `json:"tls_timeout_seconds,omitempty"`
+	ResponseTimeoutSeconds *int         `json:"response_timeout_seconds,omitempty"`
+	MaxRetries             *int         `json:"max_retries,omitempty"`
+	GroupID                *int         `json:"group_id,omitempty"` // 0 removes the connection from its group
+	Tags                   []string     `json:"tags,omitempty"`     // nil leaves the tags unchanged, an empty list clears them
+	Favorite               *bool        `json:"favorite,omitempty"`
+	SetAsDefault           *bool        `json:"set_as_default,omitempty"`
 }
 
 // Validate performs basic validation on the CreateConfigRequest
@@ -47,19 +174,373 @@ func (c *CreateConfigRequest) Validate() error {
 	if c.ConnectionName == "" {
 		return ErrConnectionNameRequired
 	}
-	if c.Host == "" {
+	if c.Host == "" && (c.CloudID == nil || *c.CloudID == "") {
 		return ErrHostRequired
 	}
+	if c.AuthenticationMethod == "apikey" && (c.APIKey == nil || *c.APIKey == "") {
+		return ErrAPIKeyRequired
+	}
+	if c.AuthenticationMethod == "bearer" && (c.BearerToken == nil || *c.BearerToken == "") {
+		return ErrBearerTokenRequired
+	}
+	if c.AuthenticationMethod == "oauth2" && (c.Username == nil || c.Password == nil) {
+		return ErrOAuthCredentialsRequired
+	}
+	if c.AuthenticationMethod == "aws_sigv4" {
+		if c.AWSRegion == nil || *c.AWSRegion == "" {
+			return ErrAWSRegionRequired
+		}
+		if err := ValidateAWSService(c.AWSService); err != nil {
+			return err
+		}
+	}
+	if c.ProxyURL != nil && *c.ProxyURL != "" {
+		if _, err := ParseProxyURL(*c.ProxyURL); err != nil {
+			return err
+		}
+	}
+	if c.SSHHost != nil && *c.SSHHost != "" {
+		if c.SSHUser == nil || *c.SSHUser == "" {
+			return ErrSSHUserRequired
+		}
+		if (c.SSHKeyFile == nil || *c.SSHKeyFile == "") && (c.SSHPassword == nil || *c.SSHPassword == "") {
+			return ErrSSHAuthRequired
+		}
+	}
+	if err := ValidateSafetyMode(c.SafetyMode); err != nil {
+		return err
+	}
+	if err := ValidateTimeouts(c.ConnectTimeoutSeconds, c.TLSTimeoutSeconds, c.ResponseTimeoutSeconds, c.MaxRetries); err != nil {
+		return err
+	}
+	return ValidateHeaders(c.DefaultHeaders)
+}
+
+// Connection timeout and retry defaults
+const (
+	DefaultConnectTimeoutSeconds  = 10
+	DefaultTLSTimeoutSeconds      = 10
+	DefaultResponseTimeoutSeconds = 60
+	DefaultMaxRetries             = 3
+)
+
+// SetDefaultTimeouts fills in the timeouts and retries left unset
+func (c *CreateConfigRequest) SetDefaultTimeouts() {
+	if c.ConnectTimeoutSeconds == nil {
+		c.ConnectTimeoutSeconds = IntPtr(DefaultConnectTimeoutSeconds)
+	}
+	if c.TLSTimeoutSeconds == nil {
+		c.TLSTimeoutSeconds = IntPtr(DefaultTLSTimeoutSeconds)
+	}
+	if c.ResponseTimeoutSeconds == nil {
+		c.ResponseTimeoutSeconds = IntPtr(DefaultResponseTimeoutSeconds)
+	}
+	if c.MaxRetries == nil {
+		c.MaxRetries = IntPtr(DefaultMaxRetries)
+	}
+}
+
+// ValidateTimeouts checks the timeouts and retry count that are set
+func ValidateTimeouts(connect, tls, response, maxRetries *int) error {
+	if connect != nil && (*connect < 1 || *connect > 300) {
+		return ErrInvalidConnectTimeout
+	}
+	if tls != nil && (*tls < 1 || *tls > 300) {
+		return ErrInvalidTLSTimeout
+	}
+	if response != nil && (*response < 1 || *response > 86400) {
+		return ErrInvalidResponseTimeout
+	}
+	if maxRetries != nil && (*maxRetries < 0 || *maxRetries > 10) {
+		return ErrInvalidMaxRetries
+	}
 	return nil
 }
 
+// Safety modes restrict which REST requests a connection accepts
+const (
+	SafetyModeReadOnly           = "read-only"           // Only requests that read data
+	SafetyModeConfirmDestructive = "confirm-destructive" // Destructive requests need a confirmation token
+	SafetyModeUnrestricted       = "unrestricted"
+)
+
+// ValidateSafetyMode checks that mode is a known safety mode; empty means unrestricted
+func ValidateSafetyMode(mode string) error {
+	switch mode {
+	case "", SafetyModeReadOnly, SafetyModeConfirmDestructive, SafetyModeUnrestricted:
+		return nil
+	}
+	return ErrInvalidSafetyMode
+}
+
+// Policies applied when the default connection is deleted
+const (
+	DefaultOnDeletePromoteRecent = "promote_most_recent" // The most recently used connection becomes the default
+	DefaultOnDeleteNone          = "none"                // Leave no default until the user picks one
+)
+
+// DefaultConnectionSettings configures how the default connection is managed
+type DefaultConnectionSettings struct {
+	OnDefaultDeleted string `json:"on_default_deleted"`
+}
+
+// Validate checks the default connection policy
+func (d *DefaultConnectionSettings) Validate() error {
+	switch d.OnDefaultDeleted {
+	case DefaultOnDeletePromoteRecent, DefaultOnDeleteNone:
+		return nil
+	}
+	return ErrInvalidDefaultPolicy
+}
+
+// ValidateAWSService checks that the SigV4 signing service is one OpenSearch accepts
+func ValidateAWSService(service *string) error {
+	if service == nil {
+		return nil
+	}
+	switch *service {
+	case "", "es", "aoss":
+		return nil
+	}
+	return ErrInvalidAWSService
+}
+
+// HTTPHeader is a header name and value sent with requests
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ValidateHeaders checks that every header has a valid HTTP token as its name
+// and a value without line breaks
+func ValidateHeaders(headers []HTTPHeader) error {
+	for _, header := range headers {
+		if !isHeaderToken(header.Name) || strings.ContainsAny(header.Value, "\r\n") {
+			return ErrInvalidHeader
+		}
+	}
+	return nil
+}
+
+// isHeaderToken reports whether name is a valid HTTP header field name
+func isHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("()<>@,;:\\\"/[]?={}", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseProxyURL parses a connection proxy URL and checks that its scheme is supported
+func ParseProxyURL(rawURL string) (*url.URL, error) {
+	proxyURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || proxyURL.Host == "" {
+		return nil, ErrInvalidProxyURL
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxyURL, nil
+	}
+	return nil, ErrInvalidProxyURL
+}
+
+// RedactedSecret replaces stored secrets in configs returned to the frontend
+const RedactedSecret = "********"
+
+// SecretFields returns pointers to the config fields that hold credentials.
+// Keep the order in sync with UpdateConfigRequest and TestConnectionRequest.
+func (c *Config) SecretFields() []**string {
+	return []**string{&c.Password, &c.APIKey, &c.BearerToken, &c.TLSClientKey, &c.ProxyPassword, &c.SSHPassword, &c.SSHKeyPassphrase,
+		&c.AWSSecretAccessKey, &c.AWSSessionToken}
+}
+
+// Redacted returns a copy of the config with every secret, including the values of the
+// default headers, replaced by RedactedSecret
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	for _, field := range redacted.SecretFields() {
+		if *field != nil && **field != "" {
+			*field = StringPtr(RedactedSecret)
+		}
+	}
+	redacted.DefaultHeaders = redactHeaders(c.DefaultHeaders)
+	return &redacted
+}
+
+// redactHeaders returns a copy of the headers with every value replaced by RedactedSecret.
+// Default headers often carry tokens, so their values are treated as secrets.
+func redactHeaders(headers []HTTPHeader) []HTTPHeader {
+	if headers == nil {
+		return nil
+	}
+	redacted := make([]HTTPHeader, len(headers))
+	for i, header := range headers {
+		redacted[i] = header
+		if header.Value != "" {
+			redacted[i].Value = RedactedSecret
+		}
+	}
+	return redacted
+}
+
+// fillRedactedHeaders replaces RedactedSecret header values with the stored value of the
+// header with the same name, matching repeated headers by their order. A placeholder
+// without a stored header is kept, as it was typed in.
+func fillRedactedHeaders(headers []HTTPHeader, stored []HTTPHeader) {
+	seen := make(map[string]int)
+	for i, header := range headers {
+		name := strings.ToLower(header.Name)
+		occurrence := seen[name]
+		seen[name]++
+		if header.Value != RedactedSecret {
+			continue
+		}
+		for _, candidate := range stored {
+			if strings.ToLower(candidate.Name) != name {
+				continue
+			}
+			if occurrence == 0 {
+				headers[i].Value = candidate.Value
+				break
+			}
+			occurrence--
+		}
+	}
+}
+
+// hasRedactedHeader reports whether a header value holds the RedactedSecret placeholder
+func hasRedactedHeader(headers []HTTPHeader) bool {
+	for _, header := range headers {
+		if header.Value == RedactedSecret {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretFields returns pointers to the credential fields, in the same order as Config.SecretFields
+func (u *UpdateConfigRequest) SecretFields() []**string {
+	return []**string{&u.Password, &u.APIKey, &u.BearerToken, &u.TLSClientKey, &u.ProxyPassword, &u.SSHPassword, &u.SSHKeyPassphrase,
+		&u.AWSSecretAccessKey, &u.AWSSessionToken}
+}
+
+// SecretFields returns pointers to the credential fields, in the same order as Config.SecretFields
+func (c *CreateConfigRequest) SecretFields() []**string {
+	return []**string{&c.Password, &c.APIKey, &c.BearerToken, &c.TLSClientKey, &c.ProxyPassword, &c.SSHPassword, &c.SSHKeyPassphrase,
+		&c.AWSSecretAccessKey, &c.AWSSessionToken}
+}
+
+// DropRedactedSecrets clears secrets that still hold the RedactedSecret placeholder,
+// so saving a form that was loaded with redacted values keeps the stored secrets.
+// Default headers are saved as a list, so redacted header values are filled in from
+// the stored headers instead.
+func (u *UpdateConfigRequest) DropRedactedSecrets(stored *Config) {
+	for _, field := range u.SecretFields() {
+		if *field != nil && **field == RedactedSecret {
+			*field = nil
+		}
+	}
+	fillRedactedHeaders(u.DefaultHeaders, stored.DefaultHeaders)
+}
+
+// ToConnectionRequest converts a stored config into the connection parameters
+// used by the Elasticsearch service, so every caller applies the same settings
+func (c *Config) ToConnectionRequest() *TestConnectionRequest {
+	return &TestConnectionRequest{
+		Host:                   c.Host,
+		Port:                   c.Port,
+		CloudID:                c.CloudID,
+		SSLOrHTTPS:             c.SSLOrHTTPS,
+		AuthenticationMethod:   c.AuthenticationMethod,
+		Username:               c.Username,
+		Password:               c.Password,
+		APIKey:                 c.APIKey,
+		BearerToken:            c.BearerToken,
+		TLSVerify:              BoolPtr(c.TLSVerify),
+		TLSCACert:              c.TLSCACert,
+		TLSCAFingerprint:       c.TLSCAFingerprint,
+		TLSClientCert:          c.TLSClientCert,
+		TLSClientKey:           c.TLSClientKey,
+		Nodes:                  c.Nodes,
+		SniffNodes:             c.SniffNodes,
+		ProxyURL:               c.ProxyURL,
+		ProxyUsername:          c.ProxyUsername,
+		ProxyPassword:          c.ProxyPassword,
+		NoProxy:                c.NoProxy,
+		SSHHost:                c.SSHHost,
+		SSHUser:                c.SSHUser,
+		SSHKeyFile:             c.SSHKeyFile,
+		SSHKeyPassphrase:       c.SSHKeyPassphrase,
+		SSHPassword:            c.SSHPassword,
+		SSHKnownHostsFile:      c.SSHKnownHostsFile,
+		SSHIgnoreHostKey:       c.SSHIgnoreHostKey,
+		PathPrefix:             c.PathPrefix,
+		DefaultHeaders:         c.DefaultHeaders,
+		AWSRegion:              c.AWSRegion,
+		AWSService:             c.AWSService,
+		AWSAccessKeyID:         c.AWSAccessKeyID,
+		AWSSecretAccessKey:     c.AWSSecretAccessKey,
+		AWSSessionToken:        c.AWSSessionToken,
+		AWSProfile:             c.AWSProfile,
+		ConnectTimeoutSeconds:  &c.ConnectTimeoutSeconds,
+		TLSTimeoutSeconds:      &c.TLSTimeoutSeconds,
+		ResponseTimeoutSeconds: &c.ResponseTimeoutSeconds,
+		MaxRetries:             &c.MaxRetries,
+		ClusterProfile:         c.ClusterProfile(),
+	}
+}
+
+// ClusterProfile returns the distribution and version detected on the last connect,
+// or nil when the connection has not been reached yet
+func (c *Config) ClusterProfile() *ClusterProfile {
+	if c.Distribution == nil || *c.Distribution == "" {
+		return nil
+	}
+	var version, buildFlavor string
+	if c.ClusterVersion != nil {
+		version = *c.ClusterVersion
+	}
+	if c.BuildFlavor != nil {
+		buildFlavor = *c.BuildFlavor
+	}
+	return NewClusterProfileFromParts(*c.Distribution, version, buildFlavor)
+}
+
 // Common validation errors
 var (
 	ErrConnectionNameRequired     = &ValidationError{Field: "connection_name", Message: "connection name is required"}
 	ErrHostRequired               = &ValidationError{Field: "host", Message: "host is required"}
+	ErrInvalidCloudID             = &ValidationError{Field: "cloud_id", Message: "Cloud ID is malformed"}
+	ErrInvalidProxyURL            = &ValidationError{Field: "proxy_url", Message: "proxy URL must be an http://, https:// or socks5:// URL with a host"}
+	ErrSSHUserRequired            = &ValidationError{Field: "ssh_user", Message: "SSH user is required for SSH tunnels"}
+	ErrSSHAuthRequired            = &ValidationError{Field: "ssh_key_file", Message: "an SSH key file or password is required for SSH tunnels"}
+	ErrInvalidHeader              = &ValidationError{Field: "default_headers", Message: "header names must be valid HTTP tokens and values must not contain line breaks"}
+	ErrAWSRegionRequired          = &ValidationError{Field: "aws_region", Message: "AWS region is required for SigV4 authentication"}
+	ErrInvalidAWSService          = &ValidationError{Field: "aws_service", Message: "AWS service must be \"es\" or \"aoss\""}
+	ErrInvalidSafetyMode          = &ValidationError{Field: "safety_mode", Message: "safety mode must be \"read-only\", \"confirm-destructive\" or \"unrestricted\""}
+	ErrInvalidExportFormat        = &ValidationError{Field: "format", Message: "export format must be \"json\" or \"yaml\""}
+	ErrInvalidConflictPolicy      = &ValidationError{Field: "conflict_policy", Message: "conflict policy must be \"skip\", \"overwrite\" or \"rename\""}
+	ErrAPIKeyRequired             = &ValidationError{Field: "api_key", Message: "API key is required for API key authentication"}
+	ErrBearerTokenRequired        = &ValidationError{Field: "bearer_token", Message: "token is required for bearer authentication"}
+	ErrOAuthCredentialsRequired   = &ValidationError{Field: "username", Message: "username and password are required for OAuth2 authentication"}
 	ErrMultipleDefaultsNotAllowed = &ValidationError{Field: "set_as_default", Message: "only one default connection is allowed"}
+	ErrInvalidConnectTimeout      = &ValidationError{Field: "connect_timeout_seconds", Message: "connect timeout must be between 1 and 300 seconds"}
+	ErrInvalidTLSTimeout          = &ValidationError{Field: "tls_timeout_seconds", Message: "TLS handshake timeout must be between 1 and 300 seconds"}
+	ErrInvalidResponseTimeout     = &ValidationError{Field: "response_timeout_seconds", Message: "response timeout must be between 1 second and 1 day"}
+	ErrInvalidMaxRetries          = &ValidationError{Field: "max_retries", Message: "retries must be between 0 and 10"}
+	ErrInvalidDefaultPolicy       = &ValidationError{Field: "on_default_deleted", Message: "policy must be \"promote_most_recent\" or \"none\""}
 	ErrMethodRequired             = &ValidationError{Field: "method", Message: "HTTP method is required"}
 	ErrEndpointRequired           = &ValidationError{Field: "endpoint", Message: "endpoint is required"}
+	ErrReservedHeader             = &ValidationError{Field: "headers", Message: "Authorization, Proxy-Authorization, Host, Content-Length, Transfer-Encoding, Connection, X-Opaque-Id and X-Amz-* headers cannot be set per request"}
+	ErrInvalidQueryParam          = &ValidationError{Field: "query_params", Message: "query parameter names cannot be empty"}
+	ErrSecretsEndpointChanged     = &ValidationError{Field: "host", Message: "saved credentials are only sent to the saved host, proxy and SSH host; enter them again to test a different endpoint"}
+	ErrInvalidExecutionID         = &ValidationError{Field: "execution_id", Message: "execution ID must be 1 to 64 letters, digits, '-', '_', '.' or ':'"}
+	ErrTooManyConnections         = &ValidationError{Field: "config_ids", Message: "a request can run on at most 20 connections at once"}
+	ErrFanOutEndpointNotRelative  = &ValidationError{Field: "endpoint", Message: "requests on several connections need a relative endpoint such as /_cat/indices"}
 )
 
 // ValidationError represents a validation error
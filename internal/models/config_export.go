@@ -0,0 +1,217 @@
+package models
+
+// ConfigExportKind identifies ElasticGaze connection export documents
+const ConfigExportKind = "elasticgaze/connections"
+
+// ConfigExportVersion is the version of the export document written by this build
+const ConfigExportVersion = 1
+
+// Export formats
+const (
+	ExportFormatJSON = "json"
+	ExportFormatYAML = "yaml"
+)
+
+// Import conflict policies for connections whose name already exists
+const (
+	ImportConflictSkip      = "skip"
+	ImportConflictOverwrite = "overwrite"
+	ImportConflictRename    = "rename"
+)
+
+// ConfigExportDocument is the portable file connections are exported to and imported from
+type ConfigExportDocument struct {
+	Kind            string                `json:"kind"`
+	Version         int                   `json:"version"`
+	ExportedAt      string                `json:"exported_at"`
+	IncludesSecrets bool                  `json:"includes_secrets"`
+	Encryption      *ExportEncryption     `json:"encryption,omitempty"`  // Set when Connections are sealed in Payload
+	Connections     []CreateConfigRequest `json:"connections,omitempty"` // Plain connections, when not encrypted
+	Payload         string                `json:"payload,omitempty"`     // Encrypted JSON array of connections
+}
+
+// ExportEncryption describes how the payload of an export document was encrypted
+type ExportEncryption struct {
+	Algorithm string `json:"algorithm"` // "aes-256-gcm"
+	KDF       string `json:"kdf"`       // "scrypt"
+	Salt      string `json:"salt"`      // Base64 salt for the key derivation
+}
+
+// ExportConfigsRequest selects the connections to export and how to write them
+type ExportConfigsRequest struct {
+	ConfigIDs      []int   `json:"config_ids,omitempty"` // Empty exports every connection
+	Format         string  `json:"format,omitempty"`     // "json" (default) or "yaml"
+	IncludeSecrets bool    `json:"include_secrets"`
+	Passphrase     *string `json:"passphrase,omitempty"` // Encrypts the connections when set
+}
+
+// ImportConfigsRequest is an export document to import and how to resolve name clashes
+type ImportConfigsRequest struct {
+	Data           string  `json:"data"` // JSON or YAML export document
+	Passphrase     *string `json:"passphrase,omitempty"`
+	ConflictPolicy string  `json:"conflict_policy,omitempty"` // "skip" (default), "overwrite" or "rename"
+}
+
+// Import actions reported per connection
+const (
+	ImportActionCreated     = "created"
+	ImportActionOverwritten = "overwritten"
+	ImportActionRenamed     = "renamed"
+	ImportActionSkipped     = "skipped"
+	ImportActionFailed      = "failed"
+)
+
+// ImportedConfig reports what happened to one connection of an import
+type ImportedConfig struct {
+	Name         string `json:"name"`                    // Name the connection was saved under
+	OriginalName string `json:"original_name,omitempty"` // Name in the file, when it was renamed
+	Action       string `json:"action"`
+	ConfigID     int    `json:"config_id,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ImportConfigsResult summarizes an import
+type ImportConfigsResult struct {
+	Created     int              `json:"created"`
+	Overwritten int              `json:"overwritten"`
+	Renamed     int              `json:"renamed"`
+	Skipped     int              `json:"skipped"`
+	Failed      int              `json:"failed"`
+	Items       []ImportedConfig `json:"items"`
+}
+
+// ValidateExportFormat checks the export format; empty means JSON
+func ValidateExportFormat(format string) error {
+	switch format {
+	case "", ExportFormatJSON, ExportFormatYAML:
+		return nil
+	}
+	return ErrInvalidExportFormat
+}
+
+// ValidateConflictPolicy checks the import conflict policy; empty means skip
+func ValidateConflictPolicy(policy string) error {
+	switch policy {
+	case "", ImportConflictSkip, ImportConflictOverwrite, ImportConflictRename:
+		return nil
+	}
+	return ErrInvalidConflictPolicy
+}
+
+// ToExport returns the user-set settings of a config as a create request, without
+// its ID, timestamps, group or detected cluster profile
+func (c *Config) ToExport() CreateConfigRequest {
+	return CreateConfigRequest{
+		ConnectionName:         c.ConnectionName,
+		EnvIndicatorColor:      c.EnvIndicatorColor,
+		Host:                   c.Host,
+		Port:                   c.Port,
+		CloudID:                c.CloudID,
+		SSLOrHTTPS:             c.SSLOrHTTPS,
+		AuthenticationMethod:   c.AuthenticationMethod,
+		Username:               c.Username,
+		Password:               c.Password,
+		APIKey:                 c.APIKey,
+		BearerToken:            c.BearerToken,
+		TLSVerify:              BoolPtr(c.TLSVerify),
+		TLSCACert:              c.TLSCACert,
+		TLSCAFingerprint:       c.TLSCAFingerprint,
+		TLSClientCert:          c.TLSClientCert,
+		TLSClientKey:           c.TLSClientKey,
+		Nodes:                  c.Nodes,
+		SniffNodes:             c.SniffNodes,
+		ProxyURL:               c.ProxyURL,
+		ProxyUsername:          c.ProxyUsername,
+		ProxyPassword:          c.ProxyPassword,
+		NoProxy:                c.NoProxy,
+		SSHHost:                c.SSHHost,
+		SSHUser:                c.SSHUser,
+		SSHKeyFile:             c.SSHKeyFile,
+		SSHKeyPassphrase:       c.SSHKeyPassphrase,
+		SSHPassword:            c.SSHPassword,
+		SSHKnownHostsFile:      c.SSHKnownHostsFile,
+		SSHIgnoreHostKey:       c.SSHIgnoreHostKey,
+		PathPrefix:             c.PathPrefix,
+		DefaultHeaders:         c.DefaultHeaders,
+		AWSRegion:              c.AWSRegion,
+		AWSService:             c.AWSService,
+		AWSAccessKeyID:         c.AWSAccessKeyID,
+		AWSSecretAccessKey:     c.AWSSecretAccessKey,
+		AWSSessionToken:        c.AWSSessionToken,
+		AWSProfile:             c.AWSProfile,
+		SafetyMode:             c.SafetyMode,
+		ConnectTimeoutSeconds:  IntPtr(c.ConnectTimeoutSeconds),
+		TLSTimeoutSeconds:      IntPtr(c.TLSTimeoutSeconds),
+		ResponseTimeoutSeconds: IntPtr(c.ResponseTimeoutSeconds),
+		MaxRetries:             IntPtr(c.MaxRetries),
+		Tags:                   c.Tags,
+		Favorite:               c.Favorite,
+		SetAsDefault:           c.SetAsDefault,
+	}
+}
+
+// ToOverwrite converts an imported connection into an update that replaces every setting
+// of an existing config. Secrets missing from the import keep their stored values.
+func (c *CreateConfigRequest) ToOverwrite() *UpdateConfigRequest {
+	return &UpdateConfigRequest{
+		EnvIndicatorColor:      &c.EnvIndicatorColor,
+		Host:                   &c.Host,
+		Port:                   &c.Port,
+		CloudID:                orEmpty(c.CloudID),
+		SSLOrHTTPS:             &c.SSLOrHTTPS,
+		AuthenticationMethod:   &c.AuthenticationMethod,
+		Username:               orEmpty(c.Username),
+		Password:               c.Password,
+		APIKey:                 c.APIKey,
+		BearerToken:            c.BearerToken,
+		TLSVerify:              BoolPtr(c.VerifiesTLS()),
+		TLSCACert:              orEmpty(c.TLSCACert),
+		TLSCAFingerprint:       orEmpty(c.TLSCAFingerprint),
+		TLSClientCert:          orEmpty(c.TLSClientCert),
+		TLSClientKey:           c.TLSClientKey,
+		Nodes:                  orEmptyList(c.Nodes),
+		SniffNodes:             &c.SniffNodes,
+		ProxyURL:               orEmpty(c.ProxyURL),
+		ProxyUsername:          orEmpty(c.ProxyUsername),
+		ProxyPassword:          c.ProxyPassword,
+		NoProxy:                orEmptyList(c.NoProxy),
+		SSHHost:                orEmpty(c.SSHHost),
+		SSHUser:                orEmpty(c.SSHUser),
+		SSHKeyFile:             orEmpty(c.SSHKeyFile),
+		SSHKeyPassphrase:       c.SSHKeyPassphrase,
+		SSHPassword:            c.SSHPassword,
+		SSHKnownHostsFile:      orEmpty(c.SSHKnownHostsFile),
+		SSHIgnoreHostKey:       &c.SSHIgnoreHostKey,
+		PathPrefix:             orEmpty(c.PathPrefix),
+		DefaultHeaders:         orEmptyList(c.DefaultHeaders),
+		AWSRegion:              orEmpty(c.AWSRegion),
+		AWSService:             orEmpty(c.AWSService),
+		AWSAccessKeyID:         orEmpty(c.AWSAccessKeyID),
+		AWSSecretAccessKey:     c.AWSSecretAccessKey,
+		AWSSessionToken:        c.AWSSessionToken,
+		AWSProfile:             orEmpty(c.AWSProfile),
+		SafetyMode:             &c.SafetyMode,
+		ConnectTimeoutSeconds:  c.ConnectTimeoutSeconds,
+		TLSTimeoutSeconds:      c.TLSTimeoutSeconds,
+		ResponseTimeoutSeconds: c.ResponseTimeoutSeconds,
+		MaxRetries:             c.MaxRetries,
+		Tags:                   orEmptyList(c.Tags),
+		Favorite:               &c.Favorite,
+	}
+}
+
+// orEmpty returns value, or a pointer to "" so an update clears the field
+func orEmpty(value *string) *string {
+	if value == nil {
+		return StringPtr("")
+	}
+	return value
+}
+
+// orEmptyList returns values, or an empty list so an update clears the field
+func orEmptyList[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
@@ -0,0 +1,94 @@
+package models
+
+import "strings"
+
+// ConfigGroup is a folder-like group of connections, e.g. per environment, team or region
+type ConfigGroup struct {
+	ID            int    `json:"id" db:"id"`
+	Name          string `json:"name" db:"name"`
+	ParentGroupID *int   `json:"parent_group_id,omitempty" db:"parent_group_id"`
+	CreatedAt     string `json:"created_at" db:"created_at"`
+	UpdatedAt     string `json:"updated_at" db:"updated_at"`
+}
+
+// CreateConfigGroupRequest represents the request payload for creating a new connection group
+type CreateConfigGroupRequest struct {
+	Name          string `json:"name" validate:"required"`
+	ParentGroupID *int   `json:"parent_group_id,omitempty"`
+}
+
+// UpdateConfigGroupRequest represents the request payload for updating a connection group
+type UpdateConfigGroupRequest struct {
+	Name          *string `json:"name,omitempty"`
+	ParentGroupID *int    `json:"parent_group_id,omitempty"` // 0 moves the group to the top level
+}
+
+// Validate performs basic validation on the CreateConfigGroupRequest
+func (g *CreateConfigGroupRequest) Validate() error {
+	if strings.TrimSpace(g.Name) == "" {
+		return ErrGroupNameRequired
+	}
+	return nil
+}
+
+// Sort fields for listing connections
+const (
+	ConfigSortCreatedAt  = "created_at"
+	ConfigSortName       = "name"
+	ConfigSortLastUsedAt = "last_used_at"
+	ConfigSortFavorite   = "favorite"
+)
+
+// ConfigFilter narrows and orders the connection list. A nil filter lists every
+// connection, newest first.
+type ConfigFilter struct {
+	GroupID          *int     `json:"group_id,omitempty"` // 0 lists ungrouped connections
+	IncludeSubgroups bool     `json:"include_subgroups"`  // Also list connections in groups nested under GroupID
+	Tags             []string `json:"tags,omitempty"`     // Connections must carry every tag, case-insensitively
+	FavoritesOnly    bool     `json:"favorites_only"`
+	Search           string   `json:"search,omitempty"`     // Matches the connection name or host
+	SortBy           string   `json:"sort_by,omitempty"`    // "created_at" (default), "name", "last_used_at" or "favorite"
+	SortOrder        string   `json:"sort_order,omitempty"` // "asc" or "desc"; defaults to "desc" for times, "asc" otherwise
+}
+
+// Validate checks the sort field and order of the filter
+func (f *ConfigFilter) Validate() error {
+	switch f.SortBy {
+	case "", ConfigSortCreatedAt, ConfigSortName, ConfigSortLastUsedAt, ConfigSortFavorite:
+	default:
+		return ErrInvalidSortField
+	}
+	switch strings.ToLower(f.SortOrder) {
+	case "", "asc", "desc":
+	default:
+		return ErrInvalidSortOrder
+	}
+	return nil
+}
+
+// NormalizeTags trims tags and drops empty and duplicate ones, comparing case-insensitively
+// and keeping the first spelling
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	normalized := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// Connection group validation errors
+var (
+	ErrGroupNameRequired = &ValidationError{Field: "name", Message: "group name is required"}
+	ErrInvalidSortField  = &ValidationError{Field: "sort_by", Message: "sort field must be \"created_at\", \"name\", \"last_used_at\" or \"favorite\""}
+	ErrInvalidSortOrder  = &ValidationError{Field: "sort_order", Message: "sort order must be \"asc\" or \"desc\""}
+)
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Credential reference kinds. A password, API key or token written as ${kind:target}
+// is resolved when a request is made, so the secret itself never reaches the database.
+const (
+	CredentialRefEnv  = "env"  // ${env:ES_PROD_PASS} reads an environment variable
+	CredentialRefFile = "file" // ${file:~/.secrets/es-prod} reads a file
+	CredentialRefCmd  = "cmd"  // ${cmd:op read op://prod/es/password} runs a helper command and reads its stdout
+)
+
+var credentialRefPattern = regexp.MustCompile(`^\$\{(env|file|cmd):(.+)\}$`)
+
+// ParseCredentialReference splits a credential reference into its kind and target.
+// Values that are not references, including ${...} with an unknown kind, are literals.
+func ParseCredentialReference(value string) (kind, target string, ok bool) {
+	match := credentialRefPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return "", "", false
+	}
+	target = strings.TrimSpace(match[2])
+	if target == "" {
+		return "", "", false
+	}
+	return match[1], target, true
+}
+
+// IsCredentialReference reports whether value is an ${env:...}, ${file:...} or ${cmd:...} reference
+func IsCredentialReference(value *string) bool {
+	if value == nil {
+		return false
+	}
+	_, _, ok := ParseCredentialReference(*value)
+	return ok
+}
+
+// UsesCredentialReference reports whether the password, API key or token is resolved
+// from the environment, a file or a helper command
+func (c *CreateConfigRequest) UsesCredentialReference() bool {
+	return IsCredentialReference(c.Password) || IsCredentialReference(c.APIKey) || IsCredentialReference(c.BearerToken)
+}
@@ -0,0 +1,88 @@
+package models
+
+// Diagnostic steps, in the order DiagnoseConnection runs them
+const (
+	DiagnosticStepDNS     = "dns"
+	DiagnosticStepTCP     = "tcp"
+	DiagnosticStepTLS     = "tls"
+	DiagnosticStepHTTP    = "http"
+	DiagnosticStepAuth    = "auth"
+	DiagnosticStepCluster = "cluster"
+)
+
+// Outcomes of a diagnostic step
+const (
+	DiagnosticPassed  = "passed"
+	DiagnosticWarning = "warning" // The step succeeded but found something worth fixing, e.g. a certificate about to expire
+	DiagnosticFailed  = "failed"
+	DiagnosticSkipped = "skipped" // Not applicable to the connection, or an earlier step failed
+)
+
+// ConnectionDiagnostics reports every stage of connecting to a cluster separately
+type ConnectionDiagnostics struct {
+	Success     bool              `json:"success"` // No step failed
+	Message     string            `json:"message"`
+	ErrorCode   string            `json:"error_code,omitempty"` // Code of the first failed step
+	Steps       []*DiagnosticStep `json:"steps"`
+	TotalMs     int64             `json:"total_ms"`
+	ClusterName string            `json:"cluster_name,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Profile     *ClusterProfile   `json:"profile,omitempty"`
+}
+
+// DiagnosticStep is the outcome of a single diagnostic stage
+type DiagnosticStep struct {
+	Name         string             `json:"name"`
+	Title        string             `json:"title"`
+	Status       string             `json:"status"`
+	DurationMs   int64              `json:"duration_ms"`
+	Message      string             `json:"message"`
+	ErrorCode    string             `json:"error_code,omitempty"`
+	ErrorDetails string             `json:"error_details,omitempty"`
+	Target       string             `json:"target,omitempty"`      // Host or address the step checked
+	Addresses    []string           `json:"addresses,omitempty"`   // DNS: every resolved address
+	TLS          *TLSDiagnostics    `json:"tls,omitempty"`         // TLS: negotiated session and presented certificates
+	StatusCode   int                `json:"status_code,omitempty"` // HTTP: status of the unauthenticated request
+	User         *AuthenticatedUser `json:"user,omitempty"`        // Auth: the resolved user
+}
+
+// TLS verification modes reported by TLSDiagnostics
+const (
+	TLSVerificationChain       = "chain"       // Certificate chain and host name are verified
+	TLSVerificationFingerprint = "fingerprint" // A certificate in the chain must match the pinned fingerprint
+	TLSVerificationNone        = "none"        // Verification is disabled
+)
+
+// TLSDiagnostics describes the TLS session negotiated with the cluster
+type TLSDiagnostics struct {
+	Version      string             `json:"version,omitempty"`
+	CipherSuite  string             `json:"cipher_suite,omitempty"`
+	ServerName   string             `json:"server_name"`
+	Verification string             `json:"verification"`
+	Verified     bool               `json:"verified"`  // The configured verification passed
+	SANMatch     bool               `json:"san_match"` // The leaf certificate is valid for ServerName
+	Certificates []*CertificateInfo `json:"certificates,omitempty"`
+}
+
+// CertificateInfo describes one certificate of the chain presented by the server, leaf first
+type CertificateInfo struct {
+	Subject         string   `json:"subject"`
+	Issuer          string   `json:"issuer"`
+	DNSNames        []string `json:"dns_names,omitempty"`
+	IPAddresses     []string `json:"ip_addresses,omitempty"`
+	NotBefore       string   `json:"not_before"`
+	NotAfter        string   `json:"not_after"`
+	DaysUntilExpiry int      `json:"days_until_expiry"`
+	Expired         bool     `json:"expired"`
+	IsCA            bool     `json:"is_ca"`
+	Fingerprint     string   `json:"fingerprint"` // SHA-256 of the DER certificate, hex encoded
+}
+
+// AuthenticatedUser is the user a connection authenticates as, as reported by the cluster
+type AuthenticatedUser struct {
+	Username           string   `json:"username"`
+	Roles              []string `json:"roles"`
+	BackendRoles       []string `json:"backend_roles,omitempty"` // OpenSearch only
+	Realm              string   `json:"realm,omitempty"`
+	AuthenticationType string   `json:"authentication_type,omitempty"`
+}
@@ -1,10 +1,44 @@
 package models
 
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MaxFanOutConnections limits how many connections a single REST request may fan out to
+const MaxFanOutConnections = 20
+
 // ElasticsearchRestRequest represents a generic REST request to Elasticsearch
 type ElasticsearchRestRequest struct {
 	Method   string  `json:"method" validate:"required"`   // HTTP method (GET, POST, PUT, DELETE, etc.)
 	Endpoint string  `json:"endpoint" validate:"required"` // Elasticsearch API endpoint (e.g., "_search", "_cat/indices")
 	Body     *string `json:"body,omitempty"`               // Request body (JSON string, optional)
+
+	// Headers are sent with this request only and take precedence over the connection's
+	// default headers, e.g. Content-Type: application/x-ndjson for _bulk. QueryParams are
+	// added to the endpoint's query string, replacing parameters of the same name.
+	Headers     []HTTPHeader      `json:"headers,omitempty"`
+	QueryParams map[string]string `json:"query_params,omitempty"`
+
+	// ConfigID selects the connection to run the request on; the default connection is used
+	// when it is unset. ConfigIDs runs the request on several connections at once instead.
+	ConfigID  *int  `json:"config_id,omitempty"`
+	ConfigIDs []int `json:"config_ids,omitempty"`
+
+	// ExecutionID identifies the request while it runs so it can be cancelled; see
+	// App.NewExecutionID. One is generated when it is empty.
+	ExecutionID string `json:"execution_id,omitempty"`
+
+	// ConfirmationToken confirms a destructive request on a "confirm-destructive" connection;
+	// it is returned with a CONFIRMATION_REQUIRED response and is valid once for the same request
+	ConfirmationToken *string `json:"confirmation_token,omitempty"`
+
+	// Overrides of the connection's response timeout and retry count for this request,
+	// e.g. for a long _reindex or _forcemerge
+	TimeoutSeconds *int `json:"timeout_seconds,omitempty"`
+	MaxRetries     *int `json:"max_retries,omitempty"`
 }
 
 // ElasticsearchRestResponse represents the response from an Elasticsearch REST request
@@ -14,6 +48,30 @@ type ElasticsearchRestResponse struct {
 	Response     string `json:"response"` // The actual Elasticsearch response as JSON string
 	ErrorDetails string `json:"error_details,omitempty"`
 	ErrorCode    string `json:"error_code,omitempty"`
+
+	ConfigID       int    `json:"config_id,omitempty"`       // Connection the request ran on
+	ConnectionName string `json:"connection_name,omitempty"` // Name of that connection, to tell fan-out results apart
+	DurationMs     int64  `json:"duration_ms"`               // Time spent on the request, including retries
+	ExecutionID    string `json:"execution_id,omitempty"`    // Sent to the cluster as X-Opaque-Id
+
+	ConfirmationToken string `json:"confirmation_token,omitempty"` // Set with CONFIRMATION_REQUIRED; resend the request with it to run it
+
+	Headers     []HTTPHeader    `json:"headers,omitempty"`      // Response headers, sorted by name
+	ContentType string          `json:"content_type,omitempty"` // e.g. "application/json; charset=UTF-8"
+	SizeBytes   int64           `json:"size_bytes"`             // Size of the response body
+	Timings     *RequestTimings `json:"timings,omitempty"`      // Phases of the last attempt
+	Warnings    []string        `json:"warnings,omitempty"`     // Deprecation notices from Warning headers
+}
+
+// RequestTimings breaks down the last attempt of a request, in milliseconds. Phases that
+// did not happen, such as DNS and connect on a reused connection, are left at zero.
+type RequestTimings struct {
+	DNSMs            float64 `json:"dns_ms,omitempty"`
+	ConnectMs        float64 `json:"connect_ms,omitempty"`
+	TLSMs            float64 `json:"tls_ms,omitempty"`
+	TTFBMs           float64 `json:"ttfb_ms"`           // From the start of the attempt to the first response byte
+	TotalMs          float64 `json:"total_ms"`          // From the start of the attempt to the end of the body
+	ConnectionReused bool    `json:"connection_reused"` // An idle keep-alive connection was used
 }
 
 // Validate performs basic validation on the ElasticsearchRestRequest
@@ -24,5 +82,90 @@ func (e *ElasticsearchRestRequest) Validate() error {
 	if e.Endpoint == "" {
 		return ErrEndpointRequired
 	}
+	if len(e.ConfigIDs) > MaxFanOutConnections {
+		return ErrTooManyConnections
+	}
+	if len(e.ConfigIDs) > 1 && IsAbsoluteURL(e.Endpoint) {
+		// A full URL names one cluster; fan-out resolves the endpoint against each connection
+		return ErrFanOutEndpointNotRelative
+	}
+	if err := ValidateHeaders(e.Headers); err != nil {
+		return err
+	}
+	for _, header := range e.Headers {
+		if isReservedRequestHeader(header.Name) {
+			return ErrReservedHeader
+		}
+	}
+	for name := range e.QueryParams {
+		if strings.TrimSpace(name) == "" {
+			return ErrInvalidQueryParam
+		}
+	}
+	return ValidateTimeouts(nil, nil, e.TimeoutSeconds, e.MaxRetries)
+}
+
+// reservedRequestHeaders are set from the connection or by the HTTP client and cannot be
+// overridden per request
+var reservedRequestHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Host":                true,
+	"Content-Length":      true,
+	"Transfer-Encoding":   true,
+	"Connection":          true,
+	"X-Opaque-Id":         true,
+}
+
+// isReservedRequestHeader reports whether a header cannot be set per request. X-Amz-*
+// headers are reserved as well: per-request headers are applied after SigV4 signing and
+// would replace the signed date, payload hash or session token.
+func isReservedRequestHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	return reservedRequestHeaders[name] || strings.HasPrefix(name, "X-Amz-")
+}
+
+// IsAbsoluteURL reports whether an endpoint is a full URL with a scheme and host rather
+// than a path relative to the connection. A "://" in the query, as in
+// _search?q=url:"http://x", does not make an endpoint absolute.
+func IsAbsoluteURL(endpoint string) bool {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// EndpointWithQuery returns the endpoint with QueryParams added to its query string
+func (e *ElasticsearchRestRequest) EndpointWithQuery() string {
+	endpoint := strings.TrimSpace(e.Endpoint)
+	if len(e.QueryParams) == 0 {
+		return endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint // Rejected when the request is created
+	}
+	query := u.Query()
+	for name, value := range e.QueryParams {
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// RunningExecution describes a cancellable request in flight
+type RunningExecution struct {
+	ExecutionID string `json:"execution_id"`
+	Description string `json:"description"`
+	ConfigIDs   []int  `json:"config_ids,omitempty"`
+	StartedAt   string `json:"started_at"`
+}
+
+var executionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+
+// ValidateExecutionID checks that a caller-chosen execution ID is safe to send as a header
+func ValidateExecutionID(id string) error {
+	if !executionIDPattern.MatchString(id) {
+		return ErrInvalidExecutionID
+	}
 	return nil
 }
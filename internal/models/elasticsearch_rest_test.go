@@ -0,0 +1,36 @@
+package models
+
+import "testing"
+
+func TestIsAbsoluteURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     bool
+	}{
+		{"_search", false},
+		{"/logs/_search?q=*", false},
+		{`_search?q=url:"http://x"`, false},
+		{"_ingest/pipeline/_simulate?source_url=https://example.com/doc.json", false},
+		{"localhost:9200/_search", false},
+		{"http://localhost:9200/_search", true},
+		{" https://search.example.com/logs/_search?q=a ", true},
+	}
+
+	for _, tt := range tests {
+		if got := IsAbsoluteURL(tt.endpoint); got != tt.want {
+			t.Errorf("IsAbsoluteURL(%q) = %v, want %v", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestFanOutAcceptsQueryWithURL(t *testing.T) {
+	req := &ElasticsearchRestRequest{Method: "GET", Endpoint: `_search?q=url:"http://x"`, ConfigIDs: []int{1, 2}}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want a relative endpoint with a URL in its query accepted", err)
+	}
+
+	req.Endpoint = "http://localhost:9200/_search"
+	if err := req.Validate(); err != ErrFanOutEndpointNotRelative {
+		t.Errorf("Validate() = %v, want ErrFanOutEndpointNotRelative", err)
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+// Statuses reported by the cluster health monitor. Green, yellow and red come from
+// _cluster/health; the others describe why no cluster status is available.
+const (
+	HealthStatusGreen       = "green"
+	HealthStatusYellow      = "yellow"
+	HealthStatusRed         = "red"
+	HealthStatusUnreachable = "unreachable" // The cluster could not be reached: DNS, TCP, TLS, proxy, tunnel or timeout
+	HealthStatusError       = "error"       // The cluster answered with an error, e.g. authentication failed
+	HealthStatusAvailable   = "available"   // Reachable, but the cluster has no health API (serverless)
+	HealthStatusUnknown     = "unknown"     // Not checked yet
+)
+
+// HealthStatusChangedEvent is the Wails event emitted with a ClusterHealthStatus whenever
+// a connection's status changes
+const HealthStatusChangedEvent = "cluster-health:changed"
+
+// ClusterHealthStatus is the latest health check result of a connection
+type ClusterHealthStatus struct {
+	ConfigID       int            `json:"config_id"`
+	ConnectionName string         `json:"connection_name"`
+	Status         string         `json:"status"`
+	PreviousStatus string         `json:"previous_status,omitempty"`
+	LatencyMs      int64          `json:"latency_ms"`
+	ErrorCode      string         `json:"error_code,omitempty"`
+	Error          string         `json:"error,omitempty"`
+	Health         *ClusterHealth `json:"health,omitempty"` // Full _cluster/health response, when available
+	CheckedAt      string         `json:"checked_at"`
+	ChangedAt      string         `json:"changed_at,omitempty"` // When Status last changed
+}
+
+// HealthMonitorSettings configures the background cluster health monitor
+type HealthMonitorSettings struct {
+	Enabled         bool `json:"enabled"`
+	IntervalSeconds int  `json:"interval_seconds"` // Time between polls
+	TimeoutSeconds  int  `json:"timeout_seconds"`  // Limit for a single health check
+	MaxConcurrent   int  `json:"max_concurrent"`   // Connections checked at the same time
+}
+
+// DefaultHealthMonitorSettings returns the settings used until the user changes them
+func DefaultHealthMonitorSettings() HealthMonitorSettings {
+	return HealthMonitorSettings{
+		Enabled:         true,
+		IntervalSeconds: 30,
+		TimeoutSeconds:  5,
+		MaxConcurrent:   8,
+	}
+}
+
+// Validate checks that the monitor settings are within sensible bounds
+func (h *HealthMonitorSettings) Validate() error {
+	if h.IntervalSeconds < 5 || h.IntervalSeconds > 86400 {
+		return ErrInvalidHealthInterval
+	}
+	if h.TimeoutSeconds < 1 || h.TimeoutSeconds > 120 || h.TimeoutSeconds > h.IntervalSeconds {
+		return ErrInvalidHealthTimeout
+	}
+	if h.MaxConcurrent < 1 || h.MaxConcurrent > 64 {
+		return ErrInvalidHealthConcurrency
+	}
+	return nil
+}
+
+// Health monitor validation errors
+var (
+	ErrInvalidHealthInterval    = &ValidationError{Field: "interval_seconds", Message: "interval must be between 5 seconds and 1 day"}
+	ErrInvalidHealthTimeout     = &ValidationError{Field: "timeout_seconds", Message: "timeout must be between 1 and 120 seconds and not longer than the interval"}
+	ErrInvalidHealthConcurrency = &ValidationError{Field: "max_concurrent", Message: "concurrency must be between 1 and 64"}
+)
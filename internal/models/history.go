@@ -0,0 +1,162 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Request history limits
+const (
+	HistoryResponseLimit     = 64 << 10 // Responses are stored up to this many bytes
+	DefaultHistoryLimit      = 100
+	DefaultHistoryMaxEntries = 10000 // Unpinned entries kept when the history prunes itself
+)
+
+// HistoryStatus values filter entries by outcome
+const (
+	HistoryStatusSuccess = "success" // 2xx responses
+	HistoryStatusError   = "error"   // Everything else, including requests that never reached the cluster
+)
+
+// historyTimeLayout is how SQLite's CURRENT_TIMESTAMP formats times
+const historyTimeLayout = "2006-01-02 15:04:05"
+
+// HistoryEntry is a REST request recorded when it was executed
+type HistoryEntry struct {
+	ID                int          `json:"id" db:"id"`
+	ConfigID          int          `json:"config_id" db:"config_id"`
+	ConnectionName    string       `json:"connection_name" db:"connection_name"` // Current name, or the name at the time if the connection was deleted
+	Method            string       `json:"method" db:"method"`
+	URL               string       `json:"url" db:"url"`                   // Endpoint as sent including query parameters, usually relative to the connection
+	Headers           []HTTPHeader `json:"headers,omitempty" db:"headers"` // Headers set on the request itself
+	Body              *string      `json:"body,omitempty" db:"body"`
+	StatusCode        int          `json:"status_code" db:"status_code"`
+	Success           bool         `json:"success" db:"success"`
+	ErrorCode         *string      `json:"error_code,omitempty" db:"error_code"`
+	ErrorDetails      *string      `json:"error_details,omitempty" db:"error_details"`
+	DurationMs        int64        `json:"duration_ms" db:"duration_ms"`
+	ResponseSize      int          `json:"response_size" db:"response_size"` // Size of the full response in bytes
+	Response          string       `json:"response" db:"response"`           // Up to HistoryResponseLimit bytes of the response
+	ResponseTruncated bool         `json:"response_truncated" db:"response_truncated"`
+	Pinned            bool         `json:"pinned" db:"pinned"` // Pinned entries are never pruned
+	ExecutedAt        string       `json:"executed_at" db:"executed_at"`
+}
+
+// HistoryFilter narrows the request history. A nil filter lists the most recent entries.
+type HistoryFilter struct {
+	ConfigID   *int   `json:"config_id,omitempty"`
+	Search     string `json:"search,omitempty"`      // Matches the URL, body or connection name
+	Method     string `json:"method,omitempty"`      // e.g. "GET"
+	Status     string `json:"status,omitempty"`      // "success" or "error"
+	StatusCode *int   `json:"status_code,omitempty"` // Exact status code, e.g. 404
+	From       string `json:"from,omitempty"`        // Date (2006-01-02) or RFC 3339 time, inclusive
+	To         string `json:"to,omitempty"`          // Date (through the end of that day) or RFC 3339 time
+	PinnedOnly bool   `json:"pinned_only"`
+	Limit      int    `json:"limit,omitempty"` // Defaults to 100
+	Offset     int    `json:"offset,omitempty"`
+}
+
+// Validate checks the status and date bounds of the filter
+func (f *HistoryFilter) Validate() error {
+	switch f.Status {
+	case "", HistoryStatusSuccess, HistoryStatusError:
+	default:
+		return ErrInvalidHistoryStatus
+	}
+	if _, err := f.FromTime(); err != nil {
+		return ErrInvalidHistoryDate
+	}
+	if _, err := f.ToTime(); err != nil {
+		return ErrInvalidHistoryDate
+	}
+	if f.Limit < 0 || f.Offset < 0 {
+		return ErrInvalidHistoryPage
+	}
+	return nil
+}
+
+// FromTime returns the lower bound in the format executed_at is stored in, or "" when unset
+func (f *HistoryFilter) FromTime() (string, error) {
+	t, _, err := parseHistoryTime(f.From)
+	if err != nil || t.IsZero() {
+		return "", err
+	}
+	return t.UTC().Format(historyTimeLayout), nil
+}
+
+// ToTime returns the exclusive upper bound in the format executed_at is stored in, or "" when unset.
+// A date includes the whole day.
+func (f *HistoryFilter) ToTime() (string, error) {
+	t, dateOnly, err := parseHistoryTime(f.To)
+	if err != nil || t.IsZero() {
+		return "", err
+	}
+	if dateOnly {
+		t = t.AddDate(0, 0, 1)
+	} else {
+		t = t.Add(time.Second) // executed_at has second precision
+	}
+	return t.UTC().Format(historyTimeLayout), nil
+}
+
+func parseHistoryTime(value string) (t time.Time, dateOnly bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// PruneHistoryRequest removes old history entries. Pinned entries are always kept.
+type PruneHistoryRequest struct {
+	OlderThanDays *int `json:"older_than_days,omitempty"` // Remove entries executed more than this many days ago
+	MaxEntries    *int `json:"max_entries,omitempty"`     // Keep at most this many of the newest entries
+	MaxSizeMB     *int `json:"max_size_mb,omitempty"`     // Keep the newest entries whose bodies and responses fit in this size
+}
+
+// Validate checks that at least one limit is given and that the limits are positive
+func (p *PruneHistoryRequest) Validate() error {
+	if p.OlderThanDays == nil && p.MaxEntries == nil && p.MaxSizeMB == nil {
+		return ErrPruneLimitRequired
+	}
+	for _, limit := range []*int{p.OlderThanDays, p.MaxEntries, p.MaxSizeMB} {
+		if limit != nil && *limit < 0 {
+			return ErrInvalidPruneLimit
+		}
+	}
+	return nil
+}
+
+// SaveHistoryRequest saves a history entry as a request in a collection
+type SaveHistoryRequest struct {
+	HistoryID    int     `json:"history_id" validate:"required"`
+	CollectionID int     `json:"collection_id" validate:"required"`
+	FolderID     *int    `json:"folder_id,omitempty"`
+	Name         string  `json:"name,omitempty"` // Defaults to the method and URL
+	Description  *string `json:"description,omitempty"`
+}
+
+// Validate checks the history entry and collection are given
+func (s *SaveHistoryRequest) Validate() error {
+	if s.HistoryID <= 0 {
+		return ErrHistoryIDRequired
+	}
+	if s.CollectionID <= 0 {
+		return ErrCollectionIDRequired
+	}
+	return nil
+}
+
+// History validation errors
+var (
+	ErrInvalidHistoryStatus = &ValidationError{Field: "status", Message: "status must be \"success\" or \"error\""}
+	ErrInvalidHistoryDate   = &ValidationError{Field: "from", Message: "dates must be YYYY-MM-DD or RFC 3339 times"}
+	ErrInvalidHistoryPage   = &ValidationError{Field: "limit", Message: "limit and offset must not be negative"}
+	ErrPruneLimitRequired   = &ValidationError{Field: "older_than_days", Message: "an age, entry count or size limit is required"}
+	ErrInvalidPruneLimit    = &ValidationError{Field: "older_than_days", Message: "prune limits must not be negative"}
+	ErrHistoryIDRequired    = &ValidationError{Field: "history_id", Message: "history entry is required"}
+)
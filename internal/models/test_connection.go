@@ -1,24 +1,64 @@
 package models
 
+import (
+	"sort"
+	"strings"
+)
+
 // TestConnectionRequest represents a request to test an Elasticsearch connection
 type TestConnectionRequest struct {
-	Host                 string  `json:"host" validate:"required"`
-	Port                 string  `json:"port"`
-	SSLOrHTTPS           bool    `json:"ssl_or_https"`
-	AuthenticationMethod string  `json:"authentication_method"`
-	Username             *string `json:"username,omitempty"`
-	Password             *string `json:"password,omitempty"`
-	APIKey               *string `json:"api_key,omitempty"`
+	ConfigID               *int            `json:"config_id,omitempty"` // Saved connection whose stored secrets replace redacted placeholders
+	Host                   string          `json:"host" validate:"required"`
+	Port                   string          `json:"port"`
+	CloudID                *string         `json:"cloud_id,omitempty"` // Elastic Cloud ID, resolved into Host/Port/SSLOrHTTPS by the service
+	SSLOrHTTPS             bool            `json:"ssl_or_https"`
+	AuthenticationMethod   string          `json:"authentication_method"`
+	Username               *string         `json:"username,omitempty"`
+	Password               *string         `json:"password,omitempty"`
+	APIKey                 *string         `json:"api_key,omitempty"`
+	BearerToken            *string         `json:"bearer_token,omitempty"`
+	TLSVerify              *bool           `json:"tls_verify,omitempty"` // Defaults to true, see VerifiesTLS
+	TLSCACert              *string         `json:"tls_ca_cert,omitempty"`
+	TLSCAFingerprint       *string         `json:"tls_ca_fingerprint,omitempty"`
+	TLSClientCert          *string         `json:"tls_client_cert,omitempty"`
+	TLSClientKey           *string         `json:"tls_client_key,omitempty"`
+	Nodes                  []string        `json:"nodes,omitempty"`
+	SniffNodes             bool            `json:"sniff_nodes"`
+	ProxyURL               *string         `json:"proxy_url,omitempty"`
+	ProxyUsername          *string         `json:"proxy_username,omitempty"`
+	ProxyPassword          *string         `json:"proxy_password,omitempty"`
+	NoProxy                []string        `json:"no_proxy,omitempty"`
+	SSHHost                *string         `json:"ssh_host,omitempty"`
+	SSHUser                *string         `json:"ssh_user,omitempty"`
+	SSHKeyFile             *string         `json:"ssh_key_file,omitempty"`
+	SSHKeyPassphrase       *string         `json:"ssh_key_passphrase,omitempty"`
+	SSHPassword            *string         `json:"ssh_password,omitempty"`
+	SSHKnownHostsFile      *string         `json:"ssh_known_hosts_file,omitempty"`
+	SSHIgnoreHostKey       bool            `json:"ssh_ignore_host_key"`
+	PathPrefix             *string         `json:"path_prefix,omitempty"`
+	DefaultHeaders         []HTTPHeader    `json:"default_headers,omitempty"`
+	AWSRegion              *string         `json:"aws_region,omitempty"`
+	AWSService             *string         `json:"aws_service,omitempty"`
+	AWSAccessKeyID         *string         `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey     *string         `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken        *string         `json:"aws_session_token,omitempty"`
+	AWSProfile             *string         `json:"aws_profile,omitempty"`
+	ConnectTimeoutSeconds  *int            `json:"connect_timeout_seconds,omitempty"` // Unset fields use the connection defaults
+	TLSTimeoutSeconds      *int            `json:"tls_timeout_seconds,omitempty"`
+	ResponseTimeoutSeconds *int            `json:"response_timeout_seconds,omitempty"`
+	MaxRetries             *int            `json:"max_retries,omitempty"`
+	ClusterProfile         *ClusterProfile `json:"-"` // Stored profile of a saved connection, see Config.ClusterProfile
 }
 
 // TestConnectionResponse represents the response from testing a connection
 type TestConnectionResponse struct {
-	Success      bool   `json:"success"`
-	Message      string `json:"message"`
-	ClusterName  string `json:"cluster_name,omitempty"`
-	Version      string `json:"version,omitempty"`
-	ErrorDetails string `json:"error_details,omitempty"`
-	ErrorCode    string `json:"error_code,omitempty"`
+	Success      bool            `json:"success"`
+	Message      string          `json:"message"`
+	ClusterName  string          `json:"cluster_name,omitempty"`
+	Version      string          `json:"version,omitempty"`
+	ErrorDetails string          `json:"error_details,omitempty"`
+	ErrorCode    string          `json:"error_code,omitempty"`
+	Profile      *ClusterProfile `json:"profile,omitempty"`
 }
 
 // Validate performs basic validation on the TestConnectionRequest
@@ -34,3 +74,77 @@ func (t *TestConnectionRequest) Validate() error {
 	}
 	return nil
 }
+
+// SecretFields returns pointers to the credential fields, in the same order as Config.SecretFields
+func (t *TestConnectionRequest) SecretFields() []**string {
+	return []**string{&t.Password, &t.APIKey, &t.BearerToken, &t.TLSClientKey, &t.ProxyPassword, &t.SSHPassword, &t.SSHKeyPassphrase,
+		&t.AWSSecretAccessKey, &t.AWSSessionToken}
+}
+
+// FillRedactedSecrets replaces RedactedSecret placeholders, in the secrets and in the
+// default header values, with the values stored on a saved config. The stored secrets are
+// only filled in while the request still targets the saved cluster, proxy and SSH host, so
+// editing the host in a form cannot send them elsewhere.
+func (t *TestConnectionRequest) FillRedactedSecrets(stored *Config) error {
+	storedFields := stored.SecretFields()
+	for i, field := range t.SecretFields() {
+		if *field == nil || **field != RedactedSecret {
+			continue
+		}
+		if !t.targetsSameEndpoints(stored) {
+			return ErrSecretsEndpointChanged
+		}
+		*field = *storedFields[i]
+	}
+	if hasRedactedHeader(t.DefaultHeaders) {
+		if !t.targetsSameEndpoints(stored) {
+			return ErrSecretsEndpointChanged
+		}
+		fillRedactedHeaders(t.DefaultHeaders, stored.DefaultHeaders)
+	}
+	return nil
+}
+
+// targetsSameEndpoints reports whether the request connects to the same cluster nodes,
+// proxy and SSH host as the stored config
+func (t *TestConnectionRequest) targetsSameEndpoints(stored *Config) bool {
+	same := func(a, b *string) bool {
+		return strings.EqualFold(strings.TrimSpace(derefOrEmpty(a)), strings.TrimSpace(derefOrEmpty(b)))
+	}
+	return strings.EqualFold(strings.TrimSpace(t.Host), strings.TrimSpace(stored.Host)) &&
+		strings.TrimSpace(t.Port) == strings.TrimSpace(stored.Port) &&
+		t.SSLOrHTTPS == stored.SSLOrHTTPS &&
+		same(t.CloudID, stored.CloudID) &&
+		same(t.ProxyURL, stored.ProxyURL) &&
+		same(t.SSHHost, stored.SSHHost) &&
+		sameNodes(t.Nodes, stored.Nodes)
+}
+
+// sameNodes compares two node lists regardless of order, case and surrounding spaces
+func sameNodes(a, b []string) bool {
+	normalize := func(nodes []string) []string {
+		var normalized []string
+		for _, node := range nodes {
+			if node = strings.ToLower(strings.TrimSpace(node)); node != "" {
+				normalized = append(normalized, node)
+			}
+		}
+		sort.Strings(normalized)
+		return normalized
+	}
+	return strings.Join(normalize(a), "\n") == strings.Join(normalize(b), "\n")
+}
+
+// derefOrEmpty returns the string a pointer refers to, or "" for nil
+func derefOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// VerifiesTLS reports whether server certificates are checked. Verification is on unless
+// turned off, and a CA bundle always turns it on since it is only used to verify.
+func (r *TestConnectionRequest) VerifiesTLS() bool {
+	return r.TLSVerify == nil || *r.TLSVerify || (r.TLSCACert != nil && strings.TrimSpace(*r.TLSCACert) != "")
+}
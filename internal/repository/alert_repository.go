@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"elasticgaze/internal/models"
+)
+
+// AlertRepository handles database operations for alert rules and triggered alerts
+type AlertRepository struct {
+	db *sql.DB
+}
+
+// NewAlertRepository creates a new alert repository
+func NewAlertRepository(db *sql.DB) *AlertRepository {
+	return &AlertRepository{db: db}
+}
+
+const alertRuleColumns = `id, config_id, name, rule_type, threshold, enabled, notify, created_at, updated_at`
+
+// scanAlertRule reads an alert rule selected with alertRuleColumns
+func scanAlertRule(row interface{ Scan(...interface{}) error }) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	err := row.Scan(
+		&rule.ID,
+		&rule.ConfigID,
+		&rule.Name,
+		&rule.RuleType,
+		&rule.Threshold,
+		&rule.Enabled,
+		&rule.Notify,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	return &rule, err
+}
+
+// CreateRule creates a new alert rule
+func (r *AlertRepository) CreateRule(rule *models.AlertRule) (*models.AlertRule, error) {
+	query := `
+		INSERT INTO tbl_alert_rules (config_id, name, rule_type, threshold, enabled, notify)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING ` + alertRuleColumns
+
+	created, err := scanAlertRule(r.db.QueryRow(query, rule.ConfigID, rule.Name, rule.RuleType, rule.Threshold, rule.Enabled, rule.Notify))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return created, nil
+}
+
+// GetRuleByID retrieves an alert rule by ID
+func (r *AlertRepository) GetRuleByID(id int) (*models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM tbl_alert_rules WHERE id = ?`
+
+	rule, err := scanAlertRule(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("alert rule with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// GetRules retrieves the alert rules of a connection, or of every connection when configID is nil
+func (r *AlertRepository) GetRules(configID *int, enabledOnly bool) ([]*models.AlertRule, error) {
+	var conditions []string
+	var args []interface{}
+	if configID != nil {
+		conditions = append(conditions, "config_id = ?")
+		args = append(args, *configID)
+	}
+	if enabledOnly {
+		conditions = append(conditions, "enabled = 1")
+	}
+
+	query := `SELECT ` + alertRuleColumns + ` FROM tbl_alert_rules`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY config_id, name COLLATE NOCASE"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*models.AlertRule{}
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// UpdateRule updates an alert rule
+func (r *AlertRepository) UpdateRule(id int, req *models.UpdateAlertRuleRequest) (*models.AlertRule, error) {
+	var setParts []string
+	var args []interface{}
+
+	if req.Name != nil {
+		setParts = append(setParts, "name = ?")
+		args = append(args, *req.Name)
+	}
+	if req.RuleType != nil {
+		setParts = append(setParts, "rule_type = ?")
+		args = append(args, *req.RuleType)
+	}
+	if req.Threshold != nil {
+		setParts = append(setParts, "threshold = ?")
+		args = append(args, *req.Threshold)
+	}
+	if req.Enabled != nil {
+		setParts = append(setParts, "enabled = ?")
+		args = append(args, *req.Enabled)
+	}
+	if req.Notify != nil {
+		setParts = append(setParts, "notify = ?")
+		args = append(args, *req.Notify)
+	}
+
+	if len(setParts) == 0 {
+		return r.GetRuleByID(id) // Nothing to update
+	}
+
+	args = append(args, id)
+	query := "UPDATE tbl_alert_rules SET " + strings.Join(setParts, ", ") + " WHERE id = ?"
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	return r.GetRuleByID(id)
+}
+
+// DeleteRule deletes an alert rule together with its alerts
+func (r *AlertRepository) DeleteRule(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM tbl_alert_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("alert rule with ID %d not found", id)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM tbl_alerts WHERE rule_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete alerts of rule: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteForConfig deletes the alert rules and alerts of a deleted connection
+func (r *AlertRepository) DeleteForConfig(configID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM tbl_alerts WHERE config_id = ?`, configID); err != nil {
+		return fmt.Errorf("failed to delete alerts: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM tbl_alert_rules WHERE config_id = ?`, configID); err != nil {
+		return fmt.Errorf("failed to delete alert rules: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+const alertColumns = `a.id, a.rule_id, a.config_id, COALESCE(c.connection_name, ''), a.rule_name, a.rule_type,
+	a.state, a.message, a.value, a.triggered_at, a.acknowledged_at, a.resolved_at`
+
+const alertFrom = ` FROM tbl_alerts a LEFT JOIN tbl_config c ON c.id = a.config_id`
+
+// scanAlert reads an alert selected with alertColumns
+func scanAlert(row interface{ Scan(...interface{}) error }) (*models.Alert, error) {
+	var alert models.Alert
+	err := row.Scan(
+		&alert.ID,
+		&alert.RuleID,
+		&alert.ConfigID,
+		&alert.ConnectionName,
+		&alert.RuleName,
+		&alert.RuleType,
+		&alert.State,
+		&alert.Message,
+		&alert.Value,
+		&alert.TriggeredAt,
+		&alert.AcknowledgedAt,
+		&alert.ResolvedAt,
+	)
+	return &alert, err
+}
+
+// CreateAlert records a newly triggered alert
+func (r *AlertRepository) CreateAlert(alert *models.Alert) (*models.Alert, error) {
+	query := `
+		INSERT INTO tbl_alerts (rule_id, config_id, rule_name, rule_type, state, message, value)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, alert.RuleID, alert.ConfigID, alert.RuleName, alert.RuleType, models.AlertStateTriggered, alert.Message, alert.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert ID: %w", err)
+	}
+
+	return r.GetAlertByID(int(id))
+}
+
+// GetAlertByID retrieves an alert by ID
+func (r *AlertRepository) GetAlertByID(id int) (*models.Alert, error) {
+	alert, err := scanAlert(r.db.QueryRow(`SELECT `+alertColumns+alertFrom+` WHERE a.id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("alert with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	return alert, nil
+}
+
+// GetAlerts retrieves alerts matching the filter, newest first
+func (r *AlertRepository) GetAlerts(filter *models.AlertFilter) ([]*models.Alert, error) {
+	var conditions []string
+	var args []interface{}
+	limit := 200
+
+	if filter != nil {
+		if filter.ConfigID != nil {
+			conditions = append(conditions, "a.config_id = ?")
+			args = append(args, *filter.ConfigID)
+		}
+		if len(filter.States) > 0 {
+			placeholders := make([]string, len(filter.States))
+			for i, state := range filter.States {
+				placeholders[i] = "?"
+				args = append(args, state)
+			}
+			conditions = append(conditions, "a.state IN ("+strings.Join(placeholders, ", ")+")")
+		}
+		if filter.Limit > 0 {
+			limit = filter.Limit
+		}
+	}
+
+	query := `SELECT ` + alertColumns + alertFrom
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY a.triggered_at DESC, a.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*models.Alert{}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+// GetOpenAlertsByRule returns the triggered or acknowledged alert of each rule that has one
+func (r *AlertRepository) GetOpenAlertsByRule() (map[int]*models.Alert, error) {
+	alerts, err := r.GetAlerts(&models.AlertFilter{
+		States: []string{models.AlertStateTriggered, models.AlertStateAcknowledged},
+		Limit:  -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	open := make(map[int]*models.Alert, len(alerts))
+	for _, alert := range alerts {
+		if _, seen := open[alert.RuleID]; !seen {
+			open[alert.RuleID] = alert
+		}
+	}
+	return open, nil
+}
+
+// AcknowledgeAlert marks a triggered alert as acknowledged
+func (r *AlertRepository) AcknowledgeAlert(id int) (*models.Alert, error) {
+	query := `UPDATE tbl_alerts SET state = ?, acknowledged_at = CURRENT_TIMESTAMP WHERE id = ? AND state = ?`
+	return r.transitionAlert(id, query, models.AlertStateAcknowledged, id, models.AlertStateTriggered)
+}
+
+// ResolveAlert marks an open alert as resolved
+func (r *AlertRepository) ResolveAlert(id int) (*models.Alert, error) {
+	query := `UPDATE tbl_alerts SET state = ?, resolved_at = CURRENT_TIMESTAMP WHERE id = ? AND state != ?`
+	return r.transitionAlert(id, query, models.AlertStateResolved, id, models.AlertStateResolved)
+}
+
+// ResolveAlertByHand marks an open alert as resolved and keeps its rule from triggering
+// again until ClearSuppression is called for it
+func (r *AlertRepository) ResolveAlertByHand(id int) (*models.Alert, error) {
+	query := `UPDATE tbl_alerts SET state = ?, resolved_at = CURRENT_TIMESTAMP, suppresses_rule = 1 WHERE id = ? AND state != ?`
+	return r.transitionAlert(id, query, models.AlertStateResolved, id, models.AlertStateResolved)
+}
+
+// GetSuppressedRules returns the IDs of the rules with an alert resolved by hand whose
+// condition has not cleared since
+func (r *AlertRepository) GetSuppressedRules() (map[int]bool, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT rule_id FROM tbl_alerts WHERE suppresses_rule = 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suppressed alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	suppressed := make(map[int]bool)
+	for rows.Next() {
+		var ruleID int
+		if err := rows.Scan(&ruleID); err != nil {
+			return nil, fmt.Errorf("failed to scan suppressed alert rule: %w", err)
+		}
+		suppressed[ruleID] = true
+	}
+	return suppressed, rows.Err()
+}
+
+// ClearSuppression lets a rule trigger again once its condition has cleared
+func (r *AlertRepository) ClearSuppression(ruleID int) error {
+	if _, err := r.db.Exec(`UPDATE tbl_alerts SET suppresses_rule = 0 WHERE rule_id = ? AND suppresses_rule = 1`, ruleID); err != nil {
+		return fmt.Errorf("failed to clear alert suppression: %w", err)
+	}
+	return nil
+}
+
+// transitionAlert runs a state change and returns the alert. Alerts already past the
+// requested state are returned unchanged.
+func (r *AlertRepository) transitionAlert(id int, query string, args ...interface{}) (*models.Alert, error) {
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update alert: %w", err)
+	}
+	return r.GetAlertByID(id)
+}
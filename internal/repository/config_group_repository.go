@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"elasticgaze/internal/models"
+)
+
+// Connection groups CRUD operations
+
+// CreateGroup creates a new connection group
+func (r *ConfigRepository) CreateGroup(req *models.CreateConfigGroupRequest) (*models.ConfigGroup, error) {
+	query := `
+		INSERT INTO tbl_config_groups (name, parent_group_id)
+		VALUES (?, ?)
+		RETURNING id, name, parent_group_id, created_at, updated_at`
+
+	var group models.ConfigGroup
+	err := r.db.QueryRow(query, req.Name, nullableGroupID(req.ParentGroupID)).Scan(
+		&group.ID,
+		&group.Name,
+		&group.ParentGroupID,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return &group, nil
+}
+
+// GetGroupByID retrieves a connection group by ID
+func (r *ConfigRepository) GetGroupByID(id int) (*models.ConfigGroup, error) {
+	query := `SELECT id, name, parent_group_id, created_at, updated_at FROM tbl_config_groups WHERE id = ?`
+
+	var group models.ConfigGroup
+	err := r.db.QueryRow(query, id).Scan(
+		&group.ID,
+		&group.Name,
+		&group.ParentGroupID,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("group with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	return &group, nil
+}
+
+// GetAllGroups retrieves every connection group ordered by name
+func (r *ConfigRepository) GetAllGroups() ([]*models.ConfigGroup, error) {
+	query := `SELECT id, name, parent_group_id, created_at, updated_at FROM tbl_config_groups ORDER BY name COLLATE NOCASE`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []*models.ConfigGroup{}
+	for rows.Next() {
+		var group models.ConfigGroup
+		err := rows.Scan(
+			&group.ID,
+			&group.Name,
+			&group.ParentGroupID,
+			&group.CreatedAt,
+			&group.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, &group)
+	}
+
+	return groups, rows.Err()
+}
+
+// UpdateGroup updates the name or parent of a connection group
+func (r *ConfigRepository) UpdateGroup(id int, req *models.UpdateConfigGroupRequest) (*models.ConfigGroup, error) {
+	var setParts []string
+	var args []interface{}
+
+	if req.Name != nil {
+		setParts = append(setParts, "name = ?")
+		args = append(args, *req.Name)
+	}
+	if req.ParentGroupID != nil {
+		setParts = append(setParts, "parent_group_id = ?")
+		args = append(args, nullableGroupID(req.ParentGroupID))
+	}
+
+	if len(setParts) == 0 {
+		return r.GetGroupByID(id) // Nothing to update
+	}
+
+	args = append(args, id)
+	query := "UPDATE tbl_config_groups SET " + strings.Join(setParts, ", ") + " WHERE id = ?"
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update group: %w", err)
+	}
+
+	return r.GetGroupByID(id)
+}
+
+// DeleteGroup deletes a connection group. Its connections and subgroups move up to
+// the group's parent, or to the top level, rather than being deleted with it.
+func (r *ConfigRepository) DeleteGroup(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var parentID sql.NullInt64
+	if err := tx.QueryRow(`SELECT parent_group_id FROM tbl_config_groups WHERE id = ?`, id).Scan(&parentID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("group with ID %d not found", id)
+		}
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE tbl_config SET group_id = ? WHERE group_id = ?`, parentID, id); err != nil {
+		return fmt.Errorf("failed to move group connections: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE tbl_config_groups SET parent_group_id = ? WHERE parent_group_id = ?`, parentID, id); err != nil {
+		return fmt.Errorf("failed to move subgroups: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM tbl_config_groups WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return tx.Commit()
+}
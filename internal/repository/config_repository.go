@@ -2,31 +2,205 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"elasticgaze/internal/models"
+	"elasticgaze/internal/secrets"
 )
 
-// ConfigRepository handles database operations for configuration
+// configColumns is the column list selected for every config query, in scanConfig order
+const configColumns = `id, connection_name, env_indicator_color, host, port, cloud_id, ssl_or_https,
+		       authentication_method, username, password, api_key, bearer_token,
+		       tls_verify, tls_ca_cert, tls_ca_fingerprint, tls_client_cert, tls_client_key,
+		       nodes, sniff_nodes, proxy_url, proxy_username, proxy_password, no_proxy,
+		       ssh_host, ssh_user, ssh_key_file, ssh_key_passphrase, ssh_password,
+		       ssh_known_hosts_file, ssh_ignore_host_key, path_prefix, default_headers,
+		       aws_region, aws_service, aws_access_key_id, aws_secret_access_key, aws_session_token, aws_profile,
+		       safety_mode, connect_timeout_seconds, tls_timeout_seconds, response_timeout_seconds, max_retries,
+		       group_id, tags, favorite, last_used_at,
+		       distribution, cluster_version, build_flavor, profile_detected_at,
+		       set_as_default, created_at, updated_at`
+
+// configSecretColumns are the columns encrypted at rest, in models.Config.SecretFields order.
+// The values of the default headers are encrypted too, inside the default_headers list.
+var configSecretColumns = []string{"password", "api_key", "bearer_token", "tls_client_key", "proxy_password",
+	"ssh_password", "ssh_key_passphrase", "aws_secret_access_key", "aws_session_token"}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanConfig scans a row selected with configColumns into a config
+func scanConfig(row rowScanner) (*models.Config, error) {
+	var config models.Config
+	var nodes, noProxy, defaultHeaders, tags sql.NullString
+	err := row.Scan(
+		&config.ID,
+		&config.ConnectionName,
+		&config.EnvIndicatorColor,
+		&config.Host,
+		&config.Port,
+		&config.CloudID,
+		&config.SSLOrHTTPS,
+		&config.AuthenticationMethod,
+		&config.Username,
+		&config.Password,
+		&config.APIKey,
+		&config.BearerToken,
+		&config.TLSVerify,
+		&config.TLSCACert,
+		&config.TLSCAFingerprint,
+		&config.TLSClientCert,
+		&config.TLSClientKey,
+		&nodes,
+		&config.SniffNodes,
+		&config.ProxyURL,
+		&config.ProxyUsername,
+		&config.ProxyPassword,
+		&noProxy,
+		&config.SSHHost,
+		&config.SSHUser,
+		&config.SSHKeyFile,
+		&config.SSHKeyPassphrase,
+		&config.SSHPassword,
+		&config.SSHKnownHostsFile,
+		&config.SSHIgnoreHostKey,
+		&config.PathPrefix,
+		&defaultHeaders,
+		&config.AWSRegion,
+		&config.AWSService,
+		&config.AWSAccessKeyID,
+		&config.AWSSecretAccessKey,
+		&config.AWSSessionToken,
+		&config.AWSProfile,
+		&config.SafetyMode,
+		&config.ConnectTimeoutSeconds,
+		&config.TLSTimeoutSeconds,
+		&config.ResponseTimeoutSeconds,
+		&config.MaxRetries,
+		&config.GroupID,
+		&tags,
+		&config.Favorite,
+		&config.LastUsedAt,
+		&config.Distribution,
+		&config.ClusterVersion,
+		&config.BuildFlavor,
+		&config.ProfileDetectedAt,
+		&config.SetAsDefault,
+		&config.CreatedAt,
+		&config.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if config.Nodes, err = decodeList[string](nodes); err != nil {
+		return nil, fmt.Errorf("failed to decode nodes: %w", err)
+	}
+	if config.NoProxy, err = decodeList[string](noProxy); err != nil {
+		return nil, fmt.Errorf("failed to decode no-proxy list: %w", err)
+	}
+	if config.DefaultHeaders, err = decodeList[models.HTTPHeader](defaultHeaders); err != nil {
+		return nil, fmt.Errorf("failed to decode default headers: %w", err)
+	}
+	if config.Tags, err = decodeList[string](tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %w", err)
+	}
+	return &config, nil
+}
+
+// encodeList stores a list as a JSON array, or NULL when it is empty
+func encodeList[T any](values []T) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// decodeList reads a list stored by encodeList
+func decodeList[T any](value sql.NullString) ([]T, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	var values []T
+	if err := json.Unmarshal([]byte(value.String), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ConfigRepository handles database operations for configuration.
+// Credentials are encrypted before they are written and decrypted when read.
 type ConfigRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	cipher *secrets.Cipher
 }
 
 // NewConfigRepository creates a new config repository
-func NewConfigRepository(db *sql.DB) *ConfigRepository {
-	return &ConfigRepository{db: db}
+func NewConfigRepository(db *sql.DB, cipher *secrets.Cipher) *ConfigRepository {
+	return &ConfigRepository{db: db, cipher: cipher}
 }
 
-// Create creates a new configuration entry
-func (r *ConfigRepository) Create(req *models.CreateConfigRequest) (*models.Config, error) {
-	// If this config is being set as default, unset all other defaults first
-	if req.SetAsDefault {
-		if err := r.unsetAllDefaults(); err != nil {
-			return nil, fmt.Errorf("failed to unset other defaults: %w", err)
+// encryptSecrets encrypts the given secret fields in place
+func (r *ConfigRepository) encryptSecrets(fields []**string) error {
+	for _, field := range fields {
+		encrypted, err := r.cipher.EncryptPtr(*field)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt secret: %w", err)
 		}
+		*field = encrypted
 	}
+	return nil
+}
 
+// encodeHeaders stores the default headers as a JSON array with encrypted values.
+// Default headers often carry tokens, so their values are encrypted like the credentials.
+func (r *ConfigRepository) encodeHeaders(headers []models.HTTPHeader) (interface{}, error) {
+	encrypted := make([]models.HTTPHeader, len(headers))
+	for i, header := range headers {
+		value, err := r.cipher.Encrypt(header.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+		}
+		encrypted[i] = models.HTTPHeader{Name: header.Name, Value: value}
+	}
+	return encodeList(encrypted)
+}
+
+// readConfig scans a config row and decrypts its secrets
+func (r *ConfigRepository) readConfig(row rowScanner) (*models.Config, error) {
+	config, err := scanConfig(row)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range config.SecretFields() {
+		decrypted, err := r.cipher.DecryptPtr(*field)
+		if err != nil {
+			return nil, fmt.Errorf("config %d: %w", config.ID, err)
+		}
+		*field = decrypted
+	}
+	for i := range config.DefaultHeaders {
+		decrypted, err := r.cipher.Decrypt(config.DefaultHeaders[i].Value)
+		if err != nil {
+			return nil, fmt.Errorf("config %d: %w", config.ID, err)
+		}
+		config.DefaultHeaders[i].Value = decrypted
+	}
+	return config, nil
+}
+
+// Create creates a new configuration entry
+func (r *ConfigRepository) Create(req *models.CreateConfigRequest) (*models.Config, error) {
 	// Set defaults
+	req.SetDefaultTimeouts()
 	if req.EnvIndicatorColor == "" {
 		req.EnvIndicatorColor = "blue"
 	}
@@ -37,40 +211,160 @@ func (r *ConfigRepository) Create(req *models.CreateConfigRequest) (*models.Conf
 		req.AuthenticationMethod = "none"
 	}
 
+	// Encrypt a copy so the returned config keeps the plaintext values
+	stored := *req
+	if err := r.encryptSecrets(stored.SecretFields()); err != nil {
+		return nil, err
+	}
+
+	nodes, err := encodeList(req.Nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode nodes: %w", err)
+	}
+	noProxy, err := encodeList(req.NoProxy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode no-proxy list: %w", err)
+	}
+	defaultHeaders, err := r.encodeHeaders(req.DefaultHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode default headers: %w", err)
+	}
+	tags, err := encodeList(req.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tags: %w", err)
+	}
+
 	query := `
 		INSERT INTO tbl_config (
-			connection_name, env_indicator_color, host, port, ssl_or_https,
-			authentication_method, username, password, set_as_default
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			connection_name, env_indicator_color, host, port, cloud_id, ssl_or_https,
+			authentication_method, username, password, api_key, bearer_token,
+			tls_verify, tls_ca_cert, tls_ca_fingerprint, tls_client_cert, tls_client_key,
+			nodes, sniff_nodes, proxy_url, proxy_username, proxy_password, no_proxy,
+			ssh_host, ssh_user, ssh_key_file, ssh_key_passphrase, ssh_password,
+			ssh_known_hosts_file, ssh_ignore_host_key, path_prefix, default_headers,
+			aws_region, aws_service, aws_access_key_id, aws_secret_access_key, aws_session_token, aws_profile,
+			safety_mode, connect_timeout_seconds, tls_timeout_seconds, response_timeout_seconds, max_retries,
+			group_id, tags, favorite, set_as_default
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING id, created_at, updated_at
 	`
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// If this config is being set as default, unset all other defaults in the same transaction
+	if req.SetAsDefault {
+		if err := unsetAllDefaults(tx); err != nil {
+			return nil, fmt.Errorf("failed to unset other defaults: %w", err)
+		}
+	}
+
 	var config models.Config
-	err := r.db.QueryRow(query,
+	err = tx.QueryRow(query,
 		req.ConnectionName,
 		req.EnvIndicatorColor,
 		req.Host,
 		req.Port,
+		req.CloudID,
 		req.SSLOrHTTPS,
 		req.AuthenticationMethod,
 		req.Username,
-		req.Password,
+		stored.Password,
+		stored.APIKey,
+		stored.BearerToken,
+		req.VerifiesTLS(),
+		req.TLSCACert,
+		req.TLSCAFingerprint,
+		req.TLSClientCert,
+		stored.TLSClientKey,
+		nodes,
+		req.SniffNodes,
+		req.ProxyURL,
+		req.ProxyUsername,
+		stored.ProxyPassword,
+		noProxy,
+		req.SSHHost,
+		req.SSHUser,
+		req.SSHKeyFile,
+		stored.SSHKeyPassphrase,
+		stored.SSHPassword,
+		req.SSHKnownHostsFile,
+		req.SSHIgnoreHostKey,
+		req.PathPrefix,
+		defaultHeaders,
+		req.AWSRegion,
+		req.AWSService,
+		req.AWSAccessKeyID,
+		stored.AWSSecretAccessKey,
+		stored.AWSSessionToken,
+		req.AWSProfile,
+		req.SafetyMode,
+		req.ConnectTimeoutSeconds,
+		req.TLSTimeoutSeconds,
+		req.ResponseTimeoutSeconds,
+		req.MaxRetries,
+		nullableGroupID(req.GroupID),
+		tags,
+		req.Favorite,
 		req.SetAsDefault,
 	).Scan(&config.ID, &config.CreatedAt, &config.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create config: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit config: %w", err)
+	}
 
 	// Fill the config struct
 	config.ConnectionName = req.ConnectionName
 	config.EnvIndicatorColor = req.EnvIndicatorColor
 	config.Host = req.Host
 	config.Port = req.Port
+	config.CloudID = req.CloudID
 	config.SSLOrHTTPS = req.SSLOrHTTPS
 	config.AuthenticationMethod = req.AuthenticationMethod
 	config.Username = req.Username
 	config.Password = req.Password
+	config.APIKey = req.APIKey
+	config.BearerToken = req.BearerToken
+	config.TLSVerify = req.VerifiesTLS()
+	config.TLSCACert = req.TLSCACert
+	config.TLSCAFingerprint = req.TLSCAFingerprint
+	config.TLSClientCert = req.TLSClientCert
+	config.TLSClientKey = req.TLSClientKey
+	config.Nodes = req.Nodes
+	config.SniffNodes = req.SniffNodes
+	config.ProxyURL = req.ProxyURL
+	config.ProxyUsername = req.ProxyUsername
+	config.ProxyPassword = req.ProxyPassword
+	config.NoProxy = req.NoProxy
+	config.SSHHost = req.SSHHost
+	config.SSHUser = req.SSHUser
+	config.SSHKeyFile = req.SSHKeyFile
+	config.SSHKeyPassphrase = req.SSHKeyPassphrase
+	config.SSHPassword = req.SSHPassword
+	config.SSHKnownHostsFile = req.SSHKnownHostsFile
+	config.SSHIgnoreHostKey = req.SSHIgnoreHostKey
+	config.PathPrefix = req.PathPrefix
+	config.DefaultHeaders = req.DefaultHeaders
+	config.AWSRegion = req.AWSRegion
+	config.AWSService = req.AWSService
+	config.AWSAccessKeyID = req.AWSAccessKeyID
+	config.AWSSecretAccessKey = req.AWSSecretAccessKey
+	config.AWSSessionToken = req.AWSSessionToken
+	config.AWSProfile = req.AWSProfile
+	config.SafetyMode = req.SafetyMode
+	config.ConnectTimeoutSeconds = *req.ConnectTimeoutSeconds
+	config.TLSTimeoutSeconds = *req.TLSTimeoutSeconds
+	config.ResponseTimeoutSeconds = *req.ResponseTimeoutSeconds
+	config.MaxRetries = *req.MaxRetries
+	config.GroupID = req.GroupID
+	config.Tags = req.Tags
+	config.Favorite = req.Favorite
 	config.SetAsDefault = req.SetAsDefault
 
 	return &config, nil
@@ -79,28 +373,12 @@ func (r *ConfigRepository) Create(req *models.CreateConfigRequest) (*models.Conf
 // GetByID retrieves a configuration by ID
 func (r *ConfigRepository) GetByID(id int) (*models.Config, error) {
 	query := `
-		SELECT id, connection_name, env_indicator_color, host, port, ssl_or_https,
-		       authentication_method, username, password, set_as_default, created_at, updated_at
+		SELECT ` + configColumns + `
 		FROM tbl_config
 		WHERE id = ?
 	`
 
-	var config models.Config
-	err := r.db.QueryRow(query, id).Scan(
-		&config.ID,
-		&config.ConnectionName,
-		&config.EnvIndicatorColor,
-		&config.Host,
-		&config.Port,
-		&config.SSLOrHTTPS,
-		&config.AuthenticationMethod,
-		&config.Username,
-		&config.Password,
-		&config.SetAsDefault,
-		&config.CreatedAt,
-		&config.UpdatedAt,
-	)
-
+	config, err := r.readConfig(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("config with ID %d not found", id)
@@ -108,19 +386,22 @@ func (r *ConfigRepository) GetByID(id int) (*models.Config, error) {
 		return nil, fmt.Errorf("failed to get config by ID: %w", err)
 	}
 
-	return &config, nil
+	return config, nil
 }
 
-// GetAll retrieves all configurations
-func (r *ConfigRepository) GetAll() ([]*models.Config, error) {
+// GetAll retrieves the configurations matching the filter, or all of them when filter is nil
+func (r *ConfigRepository) GetAll(filter *models.ConfigFilter) ([]*models.Config, error) {
+	if filter == nil {
+		filter = &models.ConfigFilter{}
+	}
+	where, args := configFilterClause(filter)
+
 	query := `
-		SELECT id, connection_name, env_indicator_color, host, port, ssl_or_https,
-		       authentication_method, username, password, set_as_default, created_at, updated_at
-		FROM tbl_config
-		ORDER BY created_at DESC
-	`
+		SELECT ` + configColumns + `
+		FROM tbl_config` + where + `
+		ORDER BY ` + configOrderClause(filter)
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all configs: %w", err)
 	}
@@ -128,25 +409,11 @@ func (r *ConfigRepository) GetAll() ([]*models.Config, error) {
 
 	var configs []*models.Config
 	for rows.Next() {
-		var config models.Config
-		err := rows.Scan(
-			&config.ID,
-			&config.ConnectionName,
-			&config.EnvIndicatorColor,
-			&config.Host,
-			&config.Port,
-			&config.SSLOrHTTPS,
-			&config.AuthenticationMethod,
-			&config.Username,
-			&config.Password,
-			&config.SetAsDefault,
-			&config.CreatedAt,
-			&config.UpdatedAt,
-		)
+		config, err := r.readConfig(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan config row: %w", err)
 		}
-		configs = append(configs, &config)
+		configs = append(configs, config)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -156,32 +423,128 @@ func (r *ConfigRepository) GetAll() ([]*models.Config, error) {
 	return configs, nil
 }
 
+// configFilterClause builds the WHERE clause and its arguments for a connection filter
+func configFilterClause(filter *models.ConfigFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.GroupID != nil {
+		switch {
+		case *filter.GroupID == 0:
+			conditions = append(conditions, "group_id IS NULL")
+		case filter.IncludeSubgroups:
+			conditions = append(conditions, `group_id IN (
+			WITH RECURSIVE subgroups(id) AS (
+				SELECT ?
+				UNION
+				SELECT g.id FROM tbl_config_groups g JOIN subgroups s ON g.parent_group_id = s.id
+			)
+			SELECT id FROM subgroups)`)
+			args = append(args, *filter.GroupID)
+		default:
+			conditions = append(conditions, "group_id = ?")
+			args = append(args, *filter.GroupID)
+		}
+	}
+
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(tbl_config.tags) WHERE json_each.value = ? COLLATE NOCASE)")
+		args = append(args, tag)
+	}
+
+	if filter.FavoritesOnly {
+		conditions = append(conditions, "favorite = 1")
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		conditions = append(conditions, "(connection_name LIKE ? OR host LIKE ?)")
+		pattern := "%" + search + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, "\n\t\t  AND "), args
+}
+
+// configOrderClause builds the ORDER BY clause for a connection filter. Connections that
+// were never used sort after used ones in either direction.
+func configOrderClause(filter *models.ConfigFilter) string {
+	switch filter.SortBy {
+	case models.ConfigSortName:
+		return "connection_name COLLATE NOCASE " + sortDirection(filter.SortOrder, "ASC") + ", id"
+	case models.ConfigSortLastUsedAt:
+		return "last_used_at IS NULL, last_used_at " + sortDirection(filter.SortOrder, "DESC") + ", connection_name COLLATE NOCASE"
+	case models.ConfigSortFavorite:
+		return "favorite " + sortDirection(filter.SortOrder, "DESC") + ", connection_name COLLATE NOCASE"
+	default:
+		order := sortDirection(filter.SortOrder, "DESC")
+		return "created_at " + order + ", id " + order
+	}
+}
+
+// sortDirection maps a sort order to a literal SQL direction, so the filter's value is
+// never written into the query. Anything other than "asc" or "desc" gets the fallback.
+func sortDirection(order, fallback string) string {
+	switch strings.ToLower(order) {
+	case "asc":
+		return "ASC"
+	case "desc":
+		return "DESC"
+	}
+	return fallback
+}
+
+// nullableGroupID stores a missing or 0 group ID as NULL
+func nullableGroupID(groupID *int) interface{} {
+	if groupID == nil || *groupID == 0 {
+		return nil
+	}
+	return *groupID
+}
+
+// TouchLastUsed records that a configuration just executed a request
+func (r *ConfigRepository) TouchLastUsed(id int) error {
+	if _, err := r.db.Exec(`UPDATE tbl_config SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to record config usage: %w", err)
+	}
+	return nil
+}
+
+// GetAllTags returns every tag in use, sorted case-insensitively
+func (r *ConfigRepository) GetAllTags() ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT json_each.value
+		FROM tbl_config, json_each(tbl_config.tags)
+		ORDER BY json_each.value COLLATE NOCASE
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
 // GetDefault retrieves the default configuration
 func (r *ConfigRepository) GetDefault() (*models.Config, error) {
 	query := `
-		SELECT id, connection_name, env_indicator_color, host, port, ssl_or_https,
-		       authentication_method, username, password, set_as_default, created_at, updated_at
+		SELECT ` + configColumns + `
 		FROM tbl_config
 		WHERE set_as_default = 1
 		LIMIT 1
 	`
 
-	var config models.Config
-	err := r.db.QueryRow(query).Scan(
-		&config.ID,
-		&config.ConnectionName,
-		&config.EnvIndicatorColor,
-		&config.Host,
-		&config.Port,
-		&config.SSLOrHTTPS,
-		&config.AuthenticationMethod,
-		&config.Username,
-		&config.Password,
-		&config.SetAsDefault,
-		&config.CreatedAt,
-		&config.UpdatedAt,
-	)
-
+	config, err := r.readConfig(r.db.QueryRow(query))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no default configuration found")
@@ -189,17 +552,17 @@ func (r *ConfigRepository) GetDefault() (*models.Config, error) {
 		return nil, fmt.Errorf("failed to get default config: %w", err)
 	}
 
-	return &config, nil
+	return config, nil
 }
 
 // Update updates an existing configuration
 func (r *ConfigRepository) Update(id int, req *models.UpdateConfigRequest) (*models.Config, error) {
-	// If this config is being set as default, unset all other defaults first
-	if req.SetAsDefault != nil && *req.SetAsDefault {
-		if err := r.unsetAllDefaults(); err != nil {
-			return nil, fmt.Errorf("failed to unset other defaults: %w", err)
-		}
+	// Encrypt a copy of the request so the caller's values are left untouched
+	stored := *req
+	if err := r.encryptSecrets(stored.SecretFields()); err != nil {
+		return nil, err
 	}
+	req = &stored
 
 	// Build dynamic query based on provided fields
 	query := "UPDATE tbl_config SET "
@@ -222,6 +585,10 @@ func (r *ConfigRepository) Update(id int, req *models.UpdateConfigRequest) (*mod
 		setParts = append(setParts, "port = ?")
 		args = append(args, *req.Port)
 	}
+	if req.CloudID != nil {
+		setParts = append(setParts, "cloud_id = ?")
+		args = append(args, *req.CloudID)
+	}
 	if req.SSLOrHTTPS != nil {
 		setParts = append(setParts, "ssl_or_https = ?")
 		args = append(args, *req.SSLOrHTTPS)
@@ -238,6 +605,166 @@ func (r *ConfigRepository) Update(id int, req *models.UpdateConfigRequest) (*mod
 		setParts = append(setParts, "password = ?")
 		args = append(args, *req.Password)
 	}
+	if req.APIKey != nil {
+		setParts = append(setParts, "api_key = ?")
+		args = append(args, *req.APIKey)
+	}
+	if req.BearerToken != nil {
+		setParts = append(setParts, "bearer_token = ?")
+		args = append(args, *req.BearerToken)
+	}
+	if req.TLSVerify != nil {
+		setParts = append(setParts, "tls_verify = ?")
+		args = append(args, *req.TLSVerify)
+	}
+	if req.TLSCACert != nil {
+		setParts = append(setParts, "tls_ca_cert = ?")
+		args = append(args, *req.TLSCACert)
+	}
+	if req.TLSCAFingerprint != nil {
+		setParts = append(setParts, "tls_ca_fingerprint = ?")
+		args = append(args, *req.TLSCAFingerprint)
+	}
+	if req.TLSClientCert != nil {
+		setParts = append(setParts, "tls_client_cert = ?")
+		args = append(args, *req.TLSClientCert)
+	}
+	if req.TLSClientKey != nil {
+		setParts = append(setParts, "tls_client_key = ?")
+		args = append(args, *req.TLSClientKey)
+	}
+	if req.Nodes != nil {
+		nodes, err := encodeList(req.Nodes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode nodes: %w", err)
+		}
+		setParts = append(setParts, "nodes = ?")
+		args = append(args, nodes)
+	}
+	if req.SniffNodes != nil {
+		setParts = append(setParts, "sniff_nodes = ?")
+		args = append(args, *req.SniffNodes)
+	}
+	if req.ProxyURL != nil {
+		setParts = append(setParts, "proxy_url = ?")
+		args = append(args, *req.ProxyURL)
+	}
+	if req.ProxyUsername != nil {
+		setParts = append(setParts, "proxy_username = ?")
+		args = append(args, *req.ProxyUsername)
+	}
+	if req.ProxyPassword != nil {
+		setParts = append(setParts, "proxy_password = ?")
+		args = append(args, *req.ProxyPassword)
+	}
+	if req.NoProxy != nil {
+		noProxy, err := encodeList(req.NoProxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode no-proxy list: %w", err)
+		}
+		setParts = append(setParts, "no_proxy = ?")
+		args = append(args, noProxy)
+	}
+	if req.SSHHost != nil {
+		setParts = append(setParts, "ssh_host = ?")
+		args = append(args, *req.SSHHost)
+	}
+	if req.SSHUser != nil {
+		setParts = append(setParts, "ssh_user = ?")
+		args = append(args, *req.SSHUser)
+	}
+	if req.SSHKeyFile != nil {
+		setParts = append(setParts, "ssh_key_file = ?")
+		args = append(args, *req.SSHKeyFile)
+	}
+	if req.SSHKeyPassphrase != nil {
+		setParts = append(setParts, "ssh_key_passphrase = ?")
+		args = append(args, *req.SSHKeyPassphrase)
+	}
+	if req.SSHPassword != nil {
+		setParts = append(setParts, "ssh_password = ?")
+		args = append(args, *req.SSHPassword)
+	}
+	if req.SSHKnownHostsFile != nil {
+		setParts = append(setParts, "ssh_known_hosts_file = ?")
+		args = append(args, *req.SSHKnownHostsFile)
+	}
+	if req.SSHIgnoreHostKey != nil {
+		setParts = append(setParts, "ssh_ignore_host_key = ?")
+		args = append(args, *req.SSHIgnoreHostKey)
+	}
+	if req.PathPrefix != nil {
+		setParts = append(setParts, "path_prefix = ?")
+		args = append(args, *req.PathPrefix)
+	}
+	if req.DefaultHeaders != nil {
+		defaultHeaders, err := r.encodeHeaders(req.DefaultHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode default headers: %w", err)
+		}
+		setParts = append(setParts, "default_headers = ?")
+		args = append(args, defaultHeaders)
+	}
+	if req.AWSRegion != nil {
+		setParts = append(setParts, "aws_region = ?")
+		args = append(args, *req.AWSRegion)
+	}
+	if req.AWSService != nil {
+		setParts = append(setParts, "aws_service = ?")
+		args = append(args, *req.AWSService)
+	}
+	if req.AWSAccessKeyID != nil {
+		setParts = append(setParts, "aws_access_key_id = ?")
+		args = append(args, *req.AWSAccessKeyID)
+	}
+	if req.AWSSecretAccessKey != nil {
+		setParts = append(setParts, "aws_secret_access_key = ?")
+		args = append(args, *req.AWSSecretAccessKey)
+	}
+	if req.AWSSessionToken != nil {
+		setParts = append(setParts, "aws_session_token = ?")
+		args = append(args, *req.AWSSessionToken)
+	}
+	if req.AWSProfile != nil {
+		setParts = append(setParts, "aws_profile = ?")
+		args = append(args, *req.AWSProfile)
+	}
+	if req.SafetyMode != nil {
+		setParts = append(setParts, "safety_mode = ?")
+		args = append(args, *req.SafetyMode)
+	}
+	if req.ConnectTimeoutSeconds != nil {
+		setParts = append(setParts, "connect_timeout_seconds = ?")
+		args = append(args, *req.ConnectTimeoutSeconds)
+	}
+	if req.TLSTimeoutSeconds != nil {
+		setParts = append(setParts, "tls_timeout_seconds = ?")
+		args = append(args, *req.TLSTimeoutSeconds)
+	}
+	if req.ResponseTimeoutSeconds != nil {
+		setParts = append(setParts, "response_timeout_seconds = ?")
+		args = append(args, *req.ResponseTimeoutSeconds)
+	}
+	if req.MaxRetries != nil {
+		setParts = append(setParts, "max_retries = ?")
+		args = append(args, *req.MaxRetries)
+	}
+	if req.GroupID != nil {
+		setParts = append(setParts, "group_id = ?")
+		args = append(args, nullableGroupID(req.GroupID))
+	}
+	if req.Tags != nil {
+		tags, err := encodeList(req.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode tags: %w", err)
+		}
+		setParts = append(setParts, "tags = ?")
+		args = append(args, tags)
+	}
+	if req.Favorite != nil {
+		setParts = append(setParts, "favorite = ?")
+		args = append(args, *req.Favorite)
+	}
 	if req.SetAsDefault != nil {
 		setParts = append(setParts, "set_as_default = ?")
 		args = append(args, *req.SetAsDefault)
@@ -255,22 +782,44 @@ func (r *ConfigRepository) Update(id int, req *models.UpdateConfigRequest) (*mod
 	query += " WHERE id = ?"
 	args = append(args, id)
 
-	_, err := r.db.Exec(query, args...)
+	tx, err := r.db.Begin()
 	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// If this config is being set as default, unset all other defaults in the same transaction
+	if req.SetAsDefault != nil && *req.SetAsDefault {
+		if err := unsetAllDefaults(tx); err != nil {
+			return nil, fmt.Errorf("failed to unset other defaults: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
 		return nil, fmt.Errorf("failed to update config: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit config update: %w", err)
+	}
 
 	// Return the updated config
 	return r.GetByID(id)
 }
 
-// Delete deletes a configuration by ID
-func (r *ConfigRepository) Delete(id int) error {
-	query := "DELETE FROM tbl_config WHERE id = ?"
+// UpdateClusterProfile stores the distribution, version and build flavor detected for a
+// connection. The row is only written when the profile differs from the stored one, so
+// loading a cluster does not count as an edit of the connection.
+func (r *ConfigRepository) UpdateClusterProfile(id int, profile *models.ClusterProfile) error {
+	query := `
+		UPDATE tbl_config
+		SET distribution = ?, cluster_version = ?, build_flavor = ?, profile_detected_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND (distribution IS NOT ? OR cluster_version IS NOT ? OR build_flavor IS NOT ?)
+	`
 
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.Exec(query, profile.Distribution, profile.Version, profile.BuildFlavor, id,
+		profile.Distribution, profile.Version, profile.BuildFlavor)
 	if err != nil {
-		return fmt.Errorf("failed to delete config: %w", err)
+		return fmt.Errorf("failed to update cluster profile: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -279,9 +828,359 @@ func (r *ConfigRepository) Delete(id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("config with ID %d not found", id)
+		var exists int
+		err := r.db.QueryRow(`SELECT 1 FROM tbl_config WHERE id = ?`, id).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("config with ID %d not found", id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update cluster profile: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes a configuration by ID. When it was the default and promoteRecent is
+// set, the most recently used remaining configuration becomes the default in the same
+// transaction. Returns the ID of the promoted configuration, if any.
+func (r *ConfigRepository) Delete(id int, promoteRecent bool) (*int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var wasDefault bool
+	if err := tx.QueryRow("SELECT set_as_default FROM tbl_config WHERE id = ?", id).Scan(&wasDefault); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("config with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_config WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to delete config: %w", err)
+	}
+
+	var promotedID *int
+	if wasDefault && promoteRecent {
+		// Never-used connections rank after used ones, newest first
+		query := `
+			UPDATE tbl_config SET set_as_default = 1
+			WHERE id = (
+				SELECT id FROM tbl_config
+				ORDER BY last_used_at IS NULL, last_used_at DESC, created_at DESC, id DESC
+				LIMIT 1
+			)
+			RETURNING id
+		`
+		var promoted int
+		err := tx.QueryRow(query).Scan(&promoted)
+		switch {
+		case err == nil:
+			promotedID = &promoted
+		case err != sql.ErrNoRows:
+			return nil, fmt.Errorf("failed to promote new default: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit config deletion: %w", err)
+	}
+
+	return promotedID, nil
+}
+
+// SetDefault makes a configuration the only default in a single transaction
+func (r *ConfigRepository) SetDefault(id int) (*models.Config, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := unsetAllDefaults(tx); err != nil {
+		return nil, fmt.Errorf("failed to unset other defaults: %w", err)
+	}
+
+	result, err := tx.Exec("UPDATE tbl_config SET set_as_default = 1 WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set default config: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("config with ID %d not found", id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit default config: %w", err)
+	}
+
+	return r.GetByID(id)
+}
+
+// EncryptExistingSecrets encrypts credentials stored in plaintext by versions before
+// encryption at rest was introduced. Encrypted values are skipped, so after the first
+// run this is a no-op and it is safe to call on every start. Returns the rows updated.
+func (r *ConfigRepository) EncryptExistingSecrets() (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stored, err := readSecretColumns(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for id, values := range stored {
+		changed := false
+		for _, field := range values.fields() {
+			if *field == nil || **field == "" || secrets.IsEncrypted(**field) {
+				continue
+			}
+			if *field, err = r.cipher.EncryptPtr(*field); err != nil {
+				return 0, fmt.Errorf("failed to encrypt secret for config %d: %w", id, err)
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if err := writeSecretColumns(tx, id, values); err != nil {
+			return 0, err
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit secret encryption: %w", err)
+	}
+
+	return updated, nil
+}
+
+// masterKeyCheckSetting is the app setting holding a value encrypted with the master
+// key, so a different key is detected at startup rather than on the first read
+const masterKeyCheckSetting = "master_key_check"
+
+// masterKeyCheckPlaintext is the value encrypted in the master key check
+const masterKeyCheckPlaintext = "elasticgaze-master-key-check"
+
+// ErrMasterKeyMismatch is returned when the stored credentials were encrypted with another master key
+var ErrMasterKeyMismatch = errors.New("stored credentials were encrypted with a different master key: " +
+	"restore the master key file they were saved with, or set " + secrets.PreviousPassphraseEnvVar +
+	" to the passphrase they were saved with")
+
+// CheckMasterKey verifies that the stored credentials can be decrypted with the current
+// master key. Databases without a check yet are verified value by value, and the check
+// is written once they all decrypt.
+func (r *ConfigRepository) CheckMasterKey() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRow(`SELECT value FROM tbl_app_settings WHERE key = ?`, masterKeyCheckSetting).Scan(&raw)
+	if err == nil {
+		var check string
+		if err := json.Unmarshal([]byte(raw), &check); err != nil {
+			return fmt.Errorf("failed to decode setting %s: %w", masterKeyCheckSetting, err)
+		}
+		if plaintext, err := r.cipher.Decrypt(check); err != nil || plaintext != masterKeyCheckPlaintext {
+			return ErrMasterKeyMismatch
+		}
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get setting %s: %w", masterKeyCheckSetting, err)
+	}
+
+	stored, err := readSecretColumns(tx)
+	if err != nil {
+		return err
+	}
+	for id, values := range stored {
+		for _, field := range values.fields() {
+			if *field == nil || !secrets.IsEncrypted(**field) {
+				continue
+			}
+			if _, err := r.cipher.Decrypt(**field); err != nil {
+				return fmt.Errorf("%w (connection %d)", ErrMasterKeyMismatch, id)
+			}
+		}
+	}
+
+	if err := r.writeMasterKeyCheck(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit master key check: %w", err)
+	}
+	return nil
+}
+
+// Rekey re-encrypts the credentials stored with the previous master key under the current
+// one, after the key source changed. Values the current key already decrypts are kept, so
+// an interrupted re-key can simply run again. Returns the rows updated.
+func (r *ConfigRepository) Rekey(previous *secrets.Cipher) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stored, err := readSecretColumns(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for id, values := range stored {
+		changed := false
+		for _, field := range values.fields() {
+			if *field == nil || !secrets.IsEncrypted(**field) {
+				continue
+			}
+			if _, err := r.cipher.Decrypt(**field); err == nil {
+				continue
+			}
+			plaintext, err := previous.Decrypt(**field)
+			if err != nil {
+				return 0, fmt.Errorf("%w (connection %d)", ErrMasterKeyMismatch, id)
+			}
+			if *field, err = r.cipher.EncryptPtr(&plaintext); err != nil {
+				return 0, fmt.Errorf("failed to encrypt secret for config %d: %w", id, err)
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if err := writeSecretColumns(tx, id, values); err != nil {
+			return 0, err
+		}
+		updated++
 	}
 
+	if err := r.writeMasterKeyCheck(tx); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit re-encryption: %w", err)
+	}
+
+	return updated, nil
+}
+
+// writeMasterKeyCheck stores the master key check encrypted with the current key
+func (r *ConfigRepository) writeMasterKeyCheck(tx *sql.Tx) error {
+	check, err := r.cipher.Encrypt(masterKeyCheckPlaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt master key check: %w", err)
+	}
+	encoded, err := json.Marshal(check)
+	if err != nil {
+		return fmt.Errorf("failed to encode setting %s: %w", masterKeyCheckSetting, err)
+	}
+
+	query := `
+		INSERT INTO tbl_app_settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`
+	if _, err := tx.Exec(query, masterKeyCheckSetting, string(encoded)); err != nil {
+		return fmt.Errorf("failed to save setting %s: %w", masterKeyCheckSetting, err)
+	}
+	return nil
+}
+
+// storedSecrets are the encrypted values of a config as stored: its secret columns and
+// the values of its default headers
+type storedSecrets struct {
+	columns      []*string
+	headers      []models.HTTPHeader
+	headerValues []*string
+}
+
+// fields returns pointers to every stored secret value
+func (s *storedSecrets) fields() []**string {
+	fields := make([]**string, 0, len(s.columns)+len(s.headerValues))
+	for i := range s.columns {
+		fields = append(fields, &s.columns[i])
+	}
+	for i := range s.headerValues {
+		fields = append(fields, &s.headerValues[i])
+	}
+	return fields
+}
+
+// readSecretColumns returns the stored secrets of every config, keyed by id
+func readSecretColumns(tx *sql.Tx) (map[int]*storedSecrets, error) {
+	rows, err := tx.Query("SELECT id, " + strings.Join(configSecretColumns, ", ") + ", default_headers FROM tbl_config")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config secrets: %w", err)
+	}
+	defer rows.Close()
+
+	stored := map[int]*storedSecrets{}
+	for rows.Next() {
+		var id int
+		var defaultHeaders sql.NullString
+		values := &storedSecrets{columns: make([]*string, len(configSecretColumns))}
+		dest := []interface{}{&id}
+		for i := range values.columns {
+			dest = append(dest, &values.columns[i])
+		}
+		dest = append(dest, &defaultHeaders)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan config secrets: %w", err)
+		}
+
+		if values.headers, err = decodeList[models.HTTPHeader](defaultHeaders); err != nil {
+			return nil, fmt.Errorf("failed to decode default headers of config %d: %w", id, err)
+		}
+		for i := range values.headers {
+			value := values.headers[i].Value
+			values.headerValues = append(values.headerValues, &value)
+		}
+		stored[id] = values
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating config secrets: %w", err)
+	}
+	return stored, nil
+}
+
+// writeSecretColumns stores the secrets of a config
+func writeSecretColumns(tx *sql.Tx, id int, values *storedSecrets) error {
+	setParts := make([]string, len(configSecretColumns))
+	args := make([]interface{}, 0, len(values.columns)+2)
+	for i, column := range configSecretColumns {
+		setParts[i] = column + " = ?"
+		args = append(args, values.columns[i])
+	}
+
+	for i := range values.headers {
+		values.headers[i].Value = *values.headerValues[i]
+	}
+	defaultHeaders, err := encodeList(values.headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode default headers of config %d: %w", id, err)
+	}
+	setParts = append(setParts, "default_headers = ?")
+	args = append(args, defaultHeaders, id)
+
+	query := "UPDATE tbl_config SET " + strings.Join(setParts, ", ") + " WHERE id = ?"
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to store encrypted secrets for config %d: %w", id, err)
+	}
 	return nil
 }
 
@@ -296,9 +1195,9 @@ func (r *ConfigRepository) HasDefaultConfig() (bool, error) {
 	return count > 0, nil
 }
 
-// unsetAllDefaults removes default flag from all configurations
-func (r *ConfigRepository) unsetAllDefaults() error {
+// unsetAllDefaults removes default flag from all configurations within a transaction
+func unsetAllDefaults(tx *sql.Tx) error {
 	query := "UPDATE tbl_config SET set_as_default = 0 WHERE set_as_default = 1"
-	_, err := r.db.Exec(query)
+	_, err := tx.Exec(query)
 	return err
 }
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"elasticgaze/internal/database"
+	"elasticgaze/internal/models"
+	"elasticgaze/internal/secrets"
+)
+
+func newTestConfigRepository(t *testing.T) *ConfigRepository {
+	t.Helper()
+	db, err := database.NewConnection(filepath.Join(t.TempDir(), "elasticgaze.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cipher, err := secrets.NewCipher(bytes.Repeat([]byte{1}, secrets.KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewConfigRepository(db.GetConnection(), cipher)
+}
+
+func TestConfigRepositoryStoresSecretsThatLookEncrypted(t *testing.T) {
+	repo := newTestConfigRepository(t)
+
+	const password = "enc:v1:not-really-encrypted"
+	const header = "enc:v1:header-value"
+	created, err := repo.Create(&models.CreateConfigRequest{
+		ConnectionName:       "local",
+		Host:                 "localhost",
+		AuthenticationMethod: "basic",
+		Username:             models.StringPtr("elastic"),
+		Password:             models.StringPtr(password),
+		DefaultHeaders:       []models.HTTPHeader{{Name: "X-Tenant", Value: header}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := repo.GetByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Password == nil || *config.Password != password {
+		t.Errorf("Password = %v, want %q", config.Password, password)
+	}
+	if len(config.DefaultHeaders) != 1 || config.DefaultHeaders[0].Value != header {
+		t.Errorf("DefaultHeaders = %v, want the value %q", config.DefaultHeaders, header)
+	}
+
+	apiKey := "enc:v1:api-key"
+	if _, err := repo.Update(created.ID, &models.UpdateConfigRequest{APIKey: &apiKey}); err != nil {
+		t.Fatal(err)
+	}
+	if config, err = repo.GetByID(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if config.APIKey == nil || *config.APIKey != apiKey {
+		t.Errorf("APIKey = %v, want %q", config.APIKey, apiKey)
+	}
+
+	if err := repo.CheckMasterKey(); err != nil {
+		t.Errorf("CheckMasterKey: %v", err)
+	}
+}
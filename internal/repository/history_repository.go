@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"elasticgaze/internal/models"
+)
+
+// HistoryRepository handles database operations for the request history
+type HistoryRepository struct {
+	db *sql.DB
+}
+
+// NewHistoryRepository creates a new history repository
+func NewHistoryRepository(db *sql.DB) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
+
+const historyColumns = `h.id, h.config_id, COALESCE(c.connection_name, h.connection_name), h.method, h.url, h.headers, h.body,
+	h.status_code, h.success, h.error_code, h.error_details, h.duration_ms, h.response_size, h.response,
+	h.response_truncated, h.pinned, h.executed_at`
+
+const historyFrom = ` FROM tbl_request_history h LEFT JOIN tbl_config c ON c.id = h.config_id`
+
+// scanHistoryEntry reads a history entry selected with historyColumns
+func scanHistoryEntry(row interface{ Scan(...interface{}) error }) (*models.HistoryEntry, error) {
+	var entry models.HistoryEntry
+	var headers sql.NullString
+	err := row.Scan(
+		&entry.ID,
+		&entry.ConfigID,
+		&entry.ConnectionName,
+		&entry.Method,
+		&entry.URL,
+		&headers,
+		&entry.Body,
+		&entry.StatusCode,
+		&entry.Success,
+		&entry.ErrorCode,
+		&entry.ErrorDetails,
+		&entry.DurationMs,
+		&entry.ResponseSize,
+		&entry.Response,
+		&entry.ResponseTruncated,
+		&entry.Pinned,
+		&entry.ExecutedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Headers, err = decodeList[models.HTTPHeader](headers); err != nil {
+		return nil, fmt.Errorf("failed to decode headers: %w", err)
+	}
+	return &entry, nil
+}
+
+// Create records an executed request and returns its ID
+func (r *HistoryRepository) Create(entry *models.HistoryEntry) (int, error) {
+	headers, err := encodeList(entry.Headers)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	query := `
+		INSERT INTO tbl_request_history (config_id, connection_name, method, url, headers, body, status_code, success,
+			error_code, error_details, duration_ms, response_size, response, response_truncated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(query,
+		entry.ConfigID,
+		entry.ConnectionName,
+		entry.Method,
+		entry.URL,
+		headers,
+		entry.Body,
+		entry.StatusCode,
+		entry.Success,
+		entry.ErrorCode,
+		entry.ErrorDetails,
+		entry.DurationMs,
+		entry.ResponseSize,
+		entry.Response,
+		entry.ResponseTruncated,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record request: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get history entry ID: %w", err)
+	}
+	return int(id), nil
+}
+
+// GetByID retrieves a history entry by ID
+func (r *HistoryRepository) GetByID(id int) (*models.HistoryEntry, error) {
+	entry, err := scanHistoryEntry(r.db.QueryRow(`SELECT `+historyColumns+historyFrom+` WHERE h.id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("history entry with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get history entry: %w", err)
+	}
+	return entry, nil
+}
+
+// GetAll retrieves the history entries matching the filter, newest first
+func (r *HistoryRepository) GetAll(filter *models.HistoryFilter) ([]*models.HistoryEntry, error) {
+	if filter == nil {
+		filter = &models.HistoryFilter{}
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if filter.ConfigID != nil {
+		conditions = append(conditions, "h.config_id = ?")
+		args = append(args, *filter.ConfigID)
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		conditions = append(conditions, "(h.url LIKE ? OR h.body LIKE ? OR COALESCE(c.connection_name, h.connection_name) LIKE ?)")
+		pattern := "%" + search + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+	if method := strings.TrimSpace(filter.Method); method != "" {
+		conditions = append(conditions, "h.method = ?")
+		args = append(args, strings.ToUpper(method))
+	}
+	switch filter.Status {
+	case models.HistoryStatusSuccess:
+		conditions = append(conditions, "h.success = 1")
+	case models.HistoryStatusError:
+		conditions = append(conditions, "h.success = 0")
+	}
+	if filter.StatusCode != nil {
+		conditions = append(conditions, "h.status_code = ?")
+		args = append(args, *filter.StatusCode)
+	}
+	if from, err := filter.FromTime(); err == nil && from != "" {
+		conditions = append(conditions, "h.executed_at >= ?")
+		args = append(args, from)
+	}
+	if to, err := filter.ToTime(); err == nil && to != "" {
+		conditions = append(conditions, "h.executed_at < ?")
+		args = append(args, to)
+	}
+	if filter.PinnedOnly {
+		conditions = append(conditions, "h.pinned = 1")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = models.DefaultHistoryLimit
+	}
+
+	query := `SELECT ` + historyColumns + historyFrom
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY h.executed_at DESC, h.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, filter.Offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.HistoryEntry{}
+	for rows.Next() {
+		entry, err := scanHistoryEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// SetPinned pins or unpins a history entry
+func (r *HistoryRepository) SetPinned(id int, pinned bool) (*models.HistoryEntry, error) {
+	result, err := r.db.Exec(`UPDATE tbl_request_history SET pinned = ? WHERE id = ?`, pinned, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin history entry: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, fmt.Errorf("history entry with ID %d not found", id)
+	}
+	return r.GetByID(id)
+}
+
+// Delete removes a history entry
+func (r *HistoryRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM tbl_request_history WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete history entry: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("history entry with ID %d not found", id)
+	}
+	return nil
+}
+
+// Prune removes unpinned entries older than the age limit, beyond the newest maxEntries,
+// or beyond the newest entries whose bodies and responses fit in maxBytes. Limits below
+// zero are ignored. It returns how many entries were removed.
+func (r *HistoryRepository) Prune(olderThanDays, maxEntries int, maxBytes int64) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var removed int64
+	prune := func(query string, args ...interface{}) error {
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to prune request history: %w", err)
+		}
+		count, _ := result.RowsAffected()
+		removed += count
+		return nil
+	}
+
+	if olderThanDays >= 0 {
+		query := `DELETE FROM tbl_request_history WHERE pinned = 0 AND executed_at < datetime('now', ?)`
+		if err := prune(query, fmt.Sprintf("-%d days", olderThanDays)); err != nil {
+			return 0, err
+		}
+	}
+	if maxEntries >= 0 {
+		query := `
+			DELETE FROM tbl_request_history WHERE id IN (
+				SELECT id FROM tbl_request_history WHERE pinned = 0
+				ORDER BY executed_at DESC, id DESC LIMIT -1 OFFSET ?
+			)`
+		if err := prune(query, maxEntries); err != nil {
+			return 0, err
+		}
+	}
+	if maxBytes >= 0 {
+		query := `
+			DELETE FROM tbl_request_history WHERE id IN (
+				SELECT id FROM (
+					SELECT id, SUM(LENGTH(CAST(response AS BLOB)) + COALESCE(LENGTH(CAST(body AS BLOB)), 0))
+						OVER (ORDER BY executed_at DESC, id DESC) AS running_size
+					FROM tbl_request_history WHERE pinned = 0
+				) WHERE running_size > ?
+			)`
+		if err := prune(query, maxBytes); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return int(removed), nil
+}
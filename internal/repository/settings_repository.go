@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// SettingsRepository stores application settings as JSON values keyed by name
+type SettingsRepository struct {
+	db *sql.DB
+}
+
+// NewSettingsRepository creates a new settings repository
+func NewSettingsRepository(db *sql.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// Get decodes the setting stored under key into value. It reports false, leaving
+// value untouched, when the setting has never been saved.
+func (r *SettingsRepository) Get(key string, value interface{}) (bool, error) {
+	var raw string
+	err := r.db.QueryRow(`SELECT value FROM tbl_app_settings WHERE key = ?`, key).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get setting %s: %w", key, err)
+	}
+
+	if err := json.Unmarshal([]byte(raw), value); err != nil {
+		return false, fmt.Errorf("failed to decode setting %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Set stores value under key, replacing any previous value
+func (r *SettingsRepository) Set(key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode setting %s: %w", key, err)
+	}
+
+	query := `
+		INSERT INTO tbl_app_settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`
+	if _, err := r.db.Exec(query, key, string(encoded)); err != nil {
+		return fmt.Errorf("failed to save setting %s: %w", key, err)
+	}
+	return nil
+}
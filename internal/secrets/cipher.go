@@ -0,0 +1,116 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marks values produced by Cipher.Encrypt so plaintext left by
+// older versions can be told apart and migrated
+const encryptedPrefix = "enc:v1:"
+
+// KeySize is the length in bytes of the AES-256 master key
+const KeySize = 32
+
+// ErrDecryptFailed is returned when a value cannot be decrypted with the current master key
+var ErrDecryptFailed = errors.New("failed to decrypt secret: wrong master key or corrupted value")
+
+// Cipher encrypts and decrypts stored secrets with AES-256-GCM
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a 32 byte master key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM cipher: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// IsEncrypted reports whether a stored value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt encrypts a plaintext value. Empty values are returned unchanged. A value that
+// merely looks encrypted is encrypted like any other, so callers migrating stored values
+// must check IsEncrypted themselves.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt. Values without the encryption
+// prefix are treated as legacy plaintext and returned unchanged.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", ErrDecryptFailed
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", ErrDecryptFailed
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrDecryptFailed
+	}
+
+	return string(plaintext), nil
+}
+
+// EncryptPtr encrypts an optional value, leaving nil untouched
+func (c *Cipher) EncryptPtr(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	encrypted, err := c.Encrypt(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &encrypted, nil
+}
+
+// DecryptPtr decrypts an optional value, leaving nil untouched
+func (c *Cipher) DecryptPtr(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	decrypted, err := c.Decrypt(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &decrypted, nil
+}
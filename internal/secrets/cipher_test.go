@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testCipher(t *testing.T, fill byte) *Cipher {
+	t.Helper()
+	c, err := NewCipher(bytes.Repeat([]byte{fill}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c := testCipher(t, 1)
+
+	for _, plaintext := range []string{"changeme", "pässwörd ✓", "id:api_key", "a much longer secret " + string(bytes.Repeat([]byte("x"), 4096))} {
+		encrypted, err := c.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(encrypted) || encrypted == plaintext {
+			t.Fatalf("Encrypt(%q) = %q, want an encrypted value", plaintext, encrypted)
+		}
+
+		decrypted, err := c.Decrypt(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decrypted != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, decrypted)
+		}
+	}
+}
+
+func TestCipherUsesFreshNonces(t *testing.T) {
+	c := testCipher(t, 1)
+
+	first, _ := c.Encrypt("changeme")
+	second, _ := c.Encrypt("changeme")
+	if first == second {
+		t.Error("encrypting the same value twice gave the same ciphertext")
+	}
+}
+
+func TestCipherPassesThroughEmptyAndLegacyValues(t *testing.T) {
+	c := testCipher(t, 1)
+
+	if encrypted, _ := c.Encrypt(""); encrypted != "" {
+		t.Errorf("Encrypt(\"\") = %q, want empty", encrypted)
+	}
+	if decrypted, err := c.Decrypt("legacy plaintext"); err != nil || decrypted != "legacy plaintext" {
+		t.Errorf("Decrypt(plaintext) = %q, %v, want the value unchanged", decrypted, err)
+	}
+
+	encrypted, _ := c.Encrypt("changeme")
+	again, _ := c.Encrypt(encrypted)
+	if again == encrypted {
+		t.Error("a value with the encryption prefix was stored unencrypted")
+	}
+	if decrypted, err := c.Decrypt(again); err != nil || decrypted != encrypted {
+		t.Errorf("Decrypt(Encrypt(%q)) = %q, %v", encrypted, decrypted, err)
+	}
+
+	if value, err := c.EncryptPtr(nil); value != nil || err != nil {
+		t.Errorf("EncryptPtr(nil) = %v, %v", value, err)
+	}
+	if value, err := c.DecryptPtr(nil); value != nil || err != nil {
+		t.Errorf("DecryptPtr(nil) = %v, %v", value, err)
+	}
+}
+
+func TestCipherRejectsOtherKeysAndTampering(t *testing.T) {
+	c := testCipher(t, 1)
+	encrypted, _ := c.Encrypt("changeme")
+
+	if _, err := testCipher(t, 2).Decrypt(encrypted); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Decrypt with another key: err = %v, want ErrDecryptFailed", err)
+	}
+
+	tampered := []byte(encrypted)
+	tampered[len(tampered)-2] ^= 1
+	if _, err := c.Decrypt(string(tampered)); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Decrypt of a tampered value: err = %v, want ErrDecryptFailed", err)
+	}
+	if _, err := c.Decrypt(encryptedPrefix + "not base64!"); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("Decrypt of a corrupted value: err = %v, want ErrDecryptFailed", err)
+	}
+}
+
+func TestNewCipherRequiresKeySize(t *testing.T) {
+	if _, err := NewCipher(make([]byte, 16)); err == nil {
+		t.Error("NewCipher accepted a 16 byte key")
+	}
+}
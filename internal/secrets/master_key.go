@@ -0,0 +1,303 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// PassphraseEnvVar holds the user passphrase the master key is derived from.
+	// When it is not set the master key is kept in a local key file instead.
+	PassphraseEnvVar = "ELASTICGAZE_MASTER_PASSPHRASE"
+
+	// PreviousPassphraseEnvVar holds the former passphrase while the passphrase is changed
+	// or the app switches back to a key file. The stored credentials are re-encrypted with
+	// the new master key at startup, after which the variable can be removed.
+	PreviousPassphraseEnvVar = "ELASTICGAZE_PREVIOUS_MASTER_PASSPHRASE"
+
+	keyFileName        = "master.key"
+	passphraseFileName = "master_passphrase.json"
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+
+	// verifierPlaintext is encrypted with a derived key so a wrong passphrase is detected at startup
+	verifierPlaintext = "elasticgaze-master-key"
+)
+
+// ErrWrongPassphrase is returned when the passphrase does not match the one used to set up encryption
+var ErrWrongPassphrase = errors.New("master passphrase does not match the stored verifier; to change the passphrase, set " +
+	PreviousPassphraseEnvVar + " to the current one")
+
+// ErrPassphraseRequired is returned when the credentials are protected by a passphrase
+// but PassphraseEnvVar is not set
+var ErrPassphraseRequired = errors.New("stored credentials are protected by a master passphrase: set " +
+	PassphraseEnvVar + ", or set " + PreviousPassphraseEnvVar + " to the passphrase to switch to a key file")
+
+// passphraseFile stores the salt and verifier for passphrase-derived master keys
+type passphraseFile struct {
+	Salt     string `json:"salt"`
+	Verifier string `json:"verifier"`
+}
+
+// MasterKeys is the master key to encrypt credentials with and, after the key source
+// changed, the previous key that stored credentials may still be encrypted with
+type MasterKeys struct {
+	Current  []byte
+	Previous []byte
+
+	retire func() error // Discards the previous key, see Retire
+}
+
+// Retire discards the previous key once every credential has been re-encrypted with the
+// current one: the old key file or passphrase file is removed, or the passphrase
+// verifier is rewritten for the new passphrase.
+func (k *MasterKeys) Retire() error {
+	if k.retire == nil {
+		return nil
+	}
+	return k.retire()
+}
+
+// LoadMasterKeys returns the app master key. A passphrase from PassphraseEnvVar is
+// used when present; otherwise a random key is read from, or created in, a key file
+// with 0600 permissions inside dir. When the source changed since the credentials were
+// stored, the key they were encrypted with is returned as the previous key: the old key
+// file when switching to a passphrase, or the key derived from PreviousPassphraseEnvVar
+// when changing the passphrase or switching back to a key file.
+func LoadMasterKeys(dir string) (*MasterKeys, error) {
+	keyPath := filepath.Join(dir, keyFileName)
+	passphrasePath := filepath.Join(dir, passphraseFileName)
+	previousPassphrase := os.Getenv(PreviousPassphraseEnvVar)
+
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return loadPassphraseKeys(keyPath, passphrasePath, passphrase, previousPassphrase)
+	}
+	return loadKeyFileKeys(keyPath, passphrasePath, previousPassphrase)
+}
+
+// loadPassphraseKeys loads the keys when a passphrase is set
+func loadPassphraseKeys(keyPath, passphrasePath, passphrase, previousPassphrase string) (*MasterKeys, error) {
+	keys := &MasterKeys{}
+	current, err := LoadPassphraseKey(passphrasePath, passphrase)
+	switch {
+	case err == nil:
+		keys.Current = current
+	case errors.Is(err, ErrWrongPassphrase) && previousPassphrase != "":
+		// Changing the passphrase: the salt is kept and the verifier rewritten once re-keyed
+		previous, err := LoadPassphraseKey(passphrasePath, previousPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := readPassphraseFile(passphrasePath)
+		if err != nil {
+			return nil, err
+		}
+		if current, err = DeriveKey(passphrase, stored.salt); err != nil {
+			return nil, err
+		}
+		keys.Current, keys.Previous = current, previous
+		keys.retire = func() error { return writePassphraseFile(passphrasePath, stored.salt, current) }
+		return keys, nil
+	default:
+		return nil, err
+	}
+
+	// Switching from a key file: its key is needed until the credentials are re-encrypted
+	previous, err := readKeyFile(keyPath)
+	if err == nil {
+		keys.Previous = previous
+		keys.retire = func() error { return os.Remove(keyPath) }
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// loadKeyFileKeys loads the keys when no passphrase is set
+func loadKeyFileKeys(keyPath, passphrasePath, previousPassphrase string) (*MasterKeys, error) {
+	keys := &MasterKeys{}
+	if _, err := os.Stat(passphrasePath); err == nil {
+		// Switching from a passphrase, which is needed until the credentials are re-encrypted
+		if previousPassphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		if keys.Previous, err = LoadPassphraseKey(passphrasePath, previousPassphrase); err != nil {
+			return nil, err
+		}
+		keys.retire = func() error { return os.Remove(passphrasePath) }
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+
+	current, err := LoadOrCreateKeyFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	keys.Current = current
+	return keys, nil
+}
+
+// readKeyFile reads a base64 master key from path
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("master key file %s is corrupted", path)
+	}
+	return key, nil
+}
+
+// LoadOrCreateKeyFile reads a base64 master key from path, generating one on first use
+func LoadOrCreateKeyFile(path string) ([]byte, error) {
+	key, err := readKeyFile(path)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read master key file: %w", err)
+	}
+
+	key = make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+
+	if err := writePrivateFile(path, []byte(base64.StdEncoding.EncodeToString(key))); err != nil {
+		return nil, fmt.Errorf("failed to write master key file: %w", err)
+	}
+
+	return key, nil
+}
+
+// LoadPassphraseKey derives the master key from a passphrase with scrypt. The salt and
+// a verifier are stored in path on first use and checked on every later start.
+func LoadPassphraseKey(path string, passphrase string) ([]byte, error) {
+	stored, err := readPassphraseFile(path)
+	if err == nil {
+		key, err := DeriveKey(passphrase, stored.salt)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkVerifier(key, stored.verifier); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := DeriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePassphraseFile(path, salt, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// storedPassphrase is the decoded content of a passphrase file
+type storedPassphrase struct {
+	salt     []byte
+	verifier string
+}
+
+// readPassphraseFile reads the salt and verifier of a passphrase-derived master key
+func readPassphraseFile(path string) (*storedPassphrase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+
+	var stored passphraseFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("passphrase file %s is corrupted", path)
+	}
+	salt, err := base64.StdEncoding.DecodeString(stored.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("passphrase file %s is corrupted", path)
+	}
+	return &storedPassphrase{salt: salt, verifier: stored.Verifier}, nil
+}
+
+// writePassphraseFile stores the salt and a verifier for the key derived with it
+func writePassphraseFile(path string, salt, key []byte) error {
+	c, err := NewCipher(key)
+	if err != nil {
+		return err
+	}
+	verifier, err := c.Encrypt(verifierPlaintext)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(passphraseFile{
+		Salt:     base64.StdEncoding.EncodeToString(salt),
+		Verifier: verifier,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode passphrase file: %w", err)
+	}
+	if err := writePrivateFile(path, content); err != nil {
+		return fmt.Errorf("failed to write passphrase file: %w", err)
+	}
+	return nil
+}
+
+// DeriveKey derives a master key from a passphrase and salt using scrypt
+func DeriveKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+// checkVerifier confirms the derived key decrypts the stored verifier
+func checkVerifier(key []byte, verifier string) error {
+	c, err := NewCipher(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := c.Decrypt(verifier)
+	if err != nil || subtle.ConstantTimeCompare([]byte(plaintext), []byte(verifierPlaintext)) != 1 {
+		return ErrWrongPassphrase
+	}
+	return nil
+}
+
+// writePrivateFile writes data readable only by the current user
+func writePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, so enforce it explicitly
+	return os.Chmod(path, 0600)
+}
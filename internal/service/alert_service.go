@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+	"elasticgaze/internal/repository"
+)
+
+// diskUsageTimeout limits the extra request made for disk watermark rules
+const diskUsageTimeout = 10 * time.Second
+
+// AlertService manages alert rules and evaluates them against health monitor results
+type AlertService struct {
+	repo          *repository.AlertRepository
+	configService *ConfigService
+	esService     *ElasticsearchService
+	emit          EventEmitter
+	notify        Notifier
+
+	mu sync.Mutex // Serializes evaluation and manual state changes
+}
+
+// NewAlertService creates a new alert service. emit and notify may be nil.
+func NewAlertService(repo *repository.AlertRepository, configService *ConfigService, esService *ElasticsearchService, emit EventEmitter, notify Notifier) *AlertService {
+	return &AlertService{
+		repo:          repo,
+		configService: configService,
+		esService:     esService,
+		emit:          emit,
+		notify:        notify,
+	}
+}
+
+// CreateAlertRule creates an alert rule for a connection
+func (s *AlertService) CreateAlertRule(req *models.CreateAlertRuleRequest) (*models.AlertRule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if _, err := s.configService.GetConfigByID(req.ConfigID); err != nil {
+		return nil, err
+	}
+
+	rule := &models.AlertRule{
+		ConfigID:  req.ConfigID,
+		Name:      strings.TrimSpace(req.Name),
+		RuleType:  req.RuleType,
+		Threshold: req.Threshold,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		Notify:    req.Notify == nil || *req.Notify,
+	}
+	if rule.Name == "" {
+		rule.Name = describeAlertRule(rule.RuleType, rule.Threshold)
+	}
+
+	return s.repo.CreateRule(rule)
+}
+
+// GetAlertRules returns the alert rules of a connection, or of every connection when configID is nil
+func (s *AlertService) GetAlertRules(configID *int) ([]*models.AlertRule, error) {
+	return s.repo.GetRules(configID, false)
+}
+
+// UpdateAlertRule updates an alert rule
+func (s *AlertService) UpdateAlertRule(id int, req *models.UpdateAlertRuleRequest) (*models.AlertRule, error) {
+	existing, err := s.repo.GetRuleByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleType := existing.RuleType
+	if req.RuleType != nil {
+		ruleType = *req.RuleType
+	}
+	threshold := existing.Threshold
+	if req.Threshold != nil {
+		threshold = req.Threshold
+	}
+	if err := models.ValidateAlertThreshold(ruleType, threshold); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		name := describeAlertRule(ruleType, threshold)
+		req.Name = &name
+	}
+
+	return s.repo.UpdateRule(id, req)
+}
+
+// DeleteAlertRule deletes an alert rule together with its alerts
+func (s *AlertService) DeleteAlertRule(id int) error {
+	return s.repo.DeleteRule(id)
+}
+
+// DeleteConfigAlerts removes the alert rules and alerts of a deleted connection
+func (s *AlertService) DeleteConfigAlerts(configID int) error {
+	return s.repo.DeleteForConfig(configID)
+}
+
+// GetAlerts returns alerts matching the filter, newest first
+func (s *AlertService) GetAlerts(filter *models.AlertFilter) ([]*models.Alert, error) {
+	return s.repo.GetAlerts(filter)
+}
+
+// AcknowledgeAlert marks a triggered alert as seen. It stays open until its condition clears.
+func (s *AlertService) AcknowledgeAlert(id int) (*models.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repo.AcknowledgeAlert(id)
+}
+
+// ResolveAlert closes an alert by hand. If the condition still holds, the rule does not
+// trigger again until the condition has cleared, also across restarts.
+func (s *AlertService) ResolveAlert(id int) (*models.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repo.ResolveAlertByHand(id)
+}
+
+// Evaluate checks every enabled rule against the latest health results, raising alerts
+// for newly breached rules and resolving alerts whose condition has cleared
+func (s *AlertService) Evaluate(ctx context.Context, statuses []*models.ClusterHealthStatus) {
+	rules, err := s.repo.GetRules(nil, true)
+	if err != nil {
+		logging.Errorf("❌ Failed to load alert rules: %v", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	byConfig := make(map[int]*models.ClusterHealthStatus, len(statuses))
+	for _, status := range statuses {
+		byConfig[status.ConfigID] = status
+	}
+
+	// Disk usage takes a request per cluster, so it is fetched before taking the lock
+	// and a slow cluster does not hold up acknowledging or resolving alerts
+	diskUsage := s.fetchDiskUsages(ctx, rules, byConfig)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	open, err := s.repo.GetOpenAlertsByRule()
+	if err != nil {
+		logging.Errorf("❌ Failed to load open alerts: %v", err)
+		return
+	}
+	suppressed, err := s.repo.GetSuppressedRules()
+	if err != nil {
+		logging.Errorf("❌ Failed to load suppressed alert rules: %v", err)
+		return
+	}
+
+	for _, rule := range rules {
+		status, ok := byConfig[rule.ConfigID]
+		if !ok {
+			continue
+		}
+
+		breached, value, message, known := evaluateRule(rule, status, diskUsage)
+		if !known {
+			continue // Keep the current state until the rule can be evaluated again
+		}
+
+		existing := open[rule.ID]
+		switch {
+		case breached && existing == nil && !suppressed[rule.ID]:
+			s.trigger(rule, status, value, message)
+		case !breached:
+			if suppressed[rule.ID] {
+				if err := s.repo.ClearSuppression(rule.ID); err != nil {
+					logging.Errorf("❌ %v", err)
+				}
+			}
+			if existing != nil {
+				s.resolve(existing)
+			}
+		}
+	}
+}
+
+// fetchDiskUsages returns the disk usage of every reachable cluster with a disk watermark
+// rule, keyed by config ID. Clusters whose usage is unavailable are left out.
+func (s *AlertService) fetchDiskUsages(ctx context.Context, rules []*models.AlertRule, byConfig map[int]*models.ClusterHealthStatus) map[int]*diskUsageResult {
+	diskUsage := make(map[int]*diskUsageResult)
+	fetched := make(map[int]bool)
+	for _, rule := range rules {
+		status, ok := byConfig[rule.ConfigID]
+		if rule.RuleType != models.AlertRuleDiskWatermark || !ok || fetched[rule.ConfigID] {
+			continue
+		}
+		if status.Status == models.HealthStatusUnreachable || status.Status == models.HealthStatusUnknown {
+			continue
+		}
+
+		fetched[rule.ConfigID] = true
+		if usage := s.fetchDiskUsage(ctx, rule.ConfigID); usage != nil {
+			diskUsage[rule.ConfigID] = usage
+		}
+	}
+	return diskUsage
+}
+
+// trigger records a new alert and announces it
+func (s *AlertService) trigger(rule *models.AlertRule, status *models.ClusterHealthStatus, value *float64, message string) {
+	alert, err := s.repo.CreateAlert(&models.Alert{
+		RuleID:   rule.ID,
+		ConfigID: rule.ConfigID,
+		RuleName: rule.Name,
+		RuleType: rule.RuleType,
+		Message:  message,
+		Value:    value,
+	})
+	if err != nil {
+		logging.Errorf("❌ Failed to record alert for rule %d: %v", rule.ID, err)
+		return
+	}
+
+	logging.Warnf("🚨 Alert on %s: %s", status.ConnectionName, message)
+	if s.emit != nil {
+		s.emit(models.AlertTriggeredEvent, alert)
+	}
+	if rule.Notify && s.notify != nil {
+		if err := s.notify("ElasticGaze: "+status.ConnectionName, message); err != nil {
+			logging.Warnf("⚠️ %v", err)
+		}
+	}
+}
+
+// resolve closes an alert whose condition has cleared
+func (s *AlertService) resolve(alert *models.Alert) {
+	resolved, err := s.repo.ResolveAlert(alert.ID)
+	if err != nil {
+		logging.Errorf("❌ Failed to resolve alert %d: %v", alert.ID, err)
+		return
+	}
+
+	logging.Infof("✅ Alert resolved on %s: %s", resolved.ConnectionName, resolved.RuleName)
+	if s.emit != nil {
+		s.emit(models.AlertResolvedEvent, resolved)
+	}
+}
+
+// evaluateRule reports whether a rule is breached, the measured value and a message.
+// known is false when the health result, or the disk usage fetched for it, does not allow
+// the rule to be evaluated.
+func evaluateRule(rule *models.AlertRule, status *models.ClusterHealthStatus, diskUsage map[int]*diskUsageResult) (breached bool, value *float64, message string, known bool) {
+	threshold := 0.0
+	if rule.Threshold != nil {
+		threshold = *rule.Threshold
+	}
+
+	if rule.RuleType == models.AlertRuleUnreachable {
+		if status.Status == models.HealthStatusUnknown {
+			return false, nil, "", false
+		}
+		return status.Status == models.HealthStatusUnreachable, nil,
+			fmt.Sprintf("Cluster is unreachable: %s", status.Error), true
+	}
+
+	if rule.RuleType == models.AlertRuleDiskWatermark {
+		if status.Status == models.HealthStatusUnreachable || status.Status == models.HealthStatusUnknown {
+			return false, nil, "", false
+		}
+		usage, ok := diskUsage[rule.ConfigID]
+		if !ok {
+			return false, nil, "", false
+		}
+		percent := usage.percent
+		return percent >= threshold, &percent,
+			fmt.Sprintf("Disk usage on node %s is %.0f%%, at or above the %.0f%% watermark", usage.node, percent, threshold), true
+	}
+
+	health := status.Health
+	if health == nil {
+		return false, nil, "", false
+	}
+
+	switch rule.RuleType {
+	case models.AlertRuleStatusRed:
+		return health.Status == models.HealthStatusRed, nil, "Cluster status is red", true
+	case models.AlertRuleUnassignedShards:
+		count := float64(health.UnassignedShards)
+		return count > threshold, &count,
+			fmt.Sprintf("%d unassigned shards, above the limit of %.0f", health.UnassignedShards, threshold), true
+	case models.AlertRuleNodeCountBelow:
+		count := float64(health.NumberOfNodes)
+		return count < threshold, &count,
+			fmt.Sprintf("Cluster has %d nodes, fewer than the expected %.0f", health.NumberOfNodes, threshold), true
+	}
+	return false, nil, "", false
+}
+
+// diskUsageResult is the fullest node's disk usage
+type diskUsageResult struct {
+	node    string
+	percent float64
+}
+
+// fetchDiskUsage returns the disk usage of the fullest node, or nil when it is unavailable
+func (s *AlertService) fetchDiskUsage(ctx context.Context, configID int) *diskUsageResult {
+	config, err := s.configService.GetConfigByID(configID)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diskUsageTimeout)
+	defer cancel()
+
+	usage, err := s.esService.maxDiskUsage(ctx, config.ToConnectionRequest())
+	if err != nil {
+		logging.Warnf("⚠️ Failed to get disk usage for %s: %v", config.ConnectionName, err)
+		return nil
+	}
+	return usage
+}
+
+// maxDiskUsage returns the disk usage of the node with the fullest disk, from _cat/allocation
+func (s *ElasticsearchService) maxDiskUsage(ctx context.Context, connReq *models.TestConnectionRequest) (*diskUsageResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.buildURL(connReq, "/_cat/allocation?format=json&h=node,disk.percent"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := s.addAuthentication(req, connReq); err != nil {
+		return nil, fmt.Errorf("failed to add authentication: %w", err)
+	}
+
+	resp, err := s.doRequest(req, connReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var rows []struct {
+		Node        string  `json:"node"`
+		DiskPercent *string `json:"disk.percent"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var fullest *diskUsageResult
+	for _, row := range rows {
+		if row.DiskPercent == nil {
+			continue // The UNASSIGNED row has no disk
+		}
+		percent, err := strconv.ParseFloat(*row.DiskPercent, 64)
+		if err != nil {
+			continue
+		}
+		if fullest == nil || percent > fullest.percent {
+			fullest = &diskUsageResult{node: row.Node, percent: percent}
+		}
+	}
+	if fullest == nil {
+		return nil, fmt.Errorf("no node reported disk usage")
+	}
+	return fullest, nil
+}
+
+// describeAlertRule returns a default rule name
+func describeAlertRule(ruleType string, threshold *float64) string {
+	value := 0.0
+	if threshold != nil {
+		value = *threshold
+	}
+	switch ruleType {
+	case models.AlertRuleStatusRed:
+		return "Cluster status is red"
+	case models.AlertRuleUnreachable:
+		return "Cluster is unreachable"
+	case models.AlertRuleUnassignedShards:
+		return fmt.Sprintf("More than %.0f unassigned shards", value)
+	case models.AlertRuleDiskWatermark:
+		return fmt.Sprintf("Disk usage at or above %.0f%%", value)
+	case models.AlertRuleNodeCountBelow:
+		return fmt.Sprintf("Fewer than %.0f nodes", value)
+	}
+	return ruleType
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"elasticgaze/internal/models"
+)
+
+// cloudDefaultPort is used when the Cloud ID does not carry an explicit port
+const cloudDefaultPort = "443"
+
+// ErrInvalidCloudID is returned when a Cloud ID cannot be decoded into an endpoint
+var ErrInvalidCloudID = errors.New("invalid Cloud ID")
+
+// cloudEndpoint is the Elasticsearch endpoint encoded in an Elastic Cloud ID
+type cloudEndpoint struct {
+	Name string
+	Host string
+	Port string
+}
+
+// decodeCloudID decodes an Elastic Cloud ID of the form "name:base64(host$es_uuid$kibana_uuid)".
+// The host may carry a port ("host:9243"); the Elasticsearch endpoint is "es_uuid.host".
+func decodeCloudID(cloudID string) (*cloudEndpoint, error) {
+	cloudID = strings.TrimSpace(cloudID)
+	if cloudID == "" {
+		return nil, fmt.Errorf("%w: value is empty", ErrInvalidCloudID)
+	}
+
+	// The deployment name is optional, and base64 never contains a colon
+	name, payload := "", cloudID
+	if idx := strings.LastIndex(cloudID, ":"); idx >= 0 {
+		name, payload = cloudID[:idx], cloudID[idx+1:]
+	}
+
+	decoded, err := decodeCloudPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload is not valid base64", ErrInvalidCloudID)
+	}
+
+	parts := strings.Split(strings.TrimSpace(decoded), "$")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("%w: expected host$es_uuid$kibana_uuid", ErrInvalidCloudID)
+	}
+
+	domain, port := parts[0], cloudDefaultPort
+	if host, p, err := net.SplitHostPort(domain); err == nil {
+		domain, port = host, p
+	}
+
+	// Some deployments override the port on the Elasticsearch UUID instead
+	esUUID := parts[1]
+	if uuid, p, err := net.SplitHostPort(esUUID); err == nil {
+		esUUID, port = uuid, p
+	}
+
+	if domain == "" || esUUID == "" || port == "" {
+		return nil, fmt.Errorf("%w: missing host or Elasticsearch UUID", ErrInvalidCloudID)
+	}
+
+	return &cloudEndpoint{
+		Name: name,
+		Host: esUUID + "." + domain,
+		Port: port,
+	}, nil
+}
+
+// decodeCloudPayload accepts padded and unpadded base64 as copied from the console
+func decodeCloudPayload(payload string) (string, error) {
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(payload); err == nil {
+			return string(decoded), nil
+		}
+	}
+	return "", fmt.Errorf("invalid base64")
+}
+
+// applyCloudID replaces the host, port and scheme of a connection with the endpoint
+// encoded in its Cloud ID. Connections without a Cloud ID are left unchanged.
+func applyCloudID(connReq *models.TestConnectionRequest) error {
+	if connReq.CloudID == nil || strings.TrimSpace(*connReq.CloudID) == "" {
+		return nil
+	}
+
+	endpoint, err := decodeCloudID(*connReq.CloudID)
+	if err != nil {
+		return err
+	}
+
+	connReq.Host = endpoint.Host
+	connReq.Port = endpoint.Port
+	connReq.SSLOrHTTPS = true
+	return nil
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+	"elasticgaze/internal/secrets"
+)
+
+const (
+	exportAlgorithm = "aes-256-gcm"
+	exportKDF       = "scrypt"
+	exportSaltSize  = 16
+)
+
+var (
+	// ErrInvalidExport is returned when imported data is not a connection export
+	ErrInvalidExport = errors.New("not an ElasticGaze connection export")
+	// ErrUnsupportedExportVersion is returned for exports written by a newer version
+	ErrUnsupportedExportVersion = errors.New("export was written by a newer version of ElasticGaze")
+	// ErrExportPassphraseRequired is returned when importing an encrypted export without a passphrase
+	ErrExportPassphraseRequired = errors.New("export is encrypted, a passphrase is required")
+	// ErrExportWrongPassphrase is returned when the passphrase does not decrypt the export
+	ErrExportWrongPassphrase = errors.New("passphrase does not match the export")
+	// ErrImportCredentialReference is returned for imported connections whose credentials
+	// are read from the environment, a file or a helper command
+	ErrImportCredentialReference = errors.New("connections with ${env:...}, ${file:...} or ${cmd:...} credential references cannot be imported; add the reference by hand")
+)
+
+// ExportConfigs writes the selected connections, or all of them, to a portable JSON or YAML document
+func (s *ConfigService) ExportConfigs(req *models.ExportConfigsRequest) (string, error) {
+	if err := models.ValidateExportFormat(req.Format); err != nil {
+		return "", fmt.Errorf("validation error: %w", err)
+	}
+
+	var configs []*models.Config
+	if len(req.ConfigIDs) == 0 {
+		all, err := s.repo.GetAll(nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to get configs: %w", err)
+		}
+		configs = all
+	} else {
+		for _, id := range req.ConfigIDs {
+			config, err := s.repo.GetByID(id)
+			if err != nil {
+				return "", fmt.Errorf("failed to get config %d: %w", id, err)
+			}
+			configs = append(configs, config)
+		}
+	}
+
+	connections := make([]models.CreateConfigRequest, len(configs))
+	for i, config := range configs {
+		connections[i] = config.ToExport()
+		if !req.IncludeSecrets {
+			for _, field := range connections[i].SecretFields() {
+				*field = nil
+			}
+			// Default header values are secrets too, and a header without its value is no use
+			connections[i].DefaultHeaders = []models.HTTPHeader{}
+		}
+	}
+
+	doc := &models.ConfigExportDocument{
+		Kind:            models.ConfigExportKind,
+		Version:         models.ConfigExportVersion,
+		ExportedAt:      time.Now().UTC().Format(time.RFC3339),
+		IncludesSecrets: req.IncludeSecrets,
+	}
+
+	passphrase := derefString(req.Passphrase)
+	if passphrase != "" {
+		encryption, payload, err := sealConnections(connections, passphrase)
+		if err != nil {
+			return "", err
+		}
+		doc.Encryption = encryption
+		doc.Payload = payload
+	} else {
+		doc.Connections = connections
+		if req.IncludeSecrets {
+			logging.Warnf("⚠️ Exporting %d connection(s) with unencrypted secrets", len(connections))
+		}
+	}
+
+	encoded, err := encodeExport(doc, req.Format)
+	if err != nil {
+		return "", err
+	}
+
+	logging.Infof("📤 Exported %d connection(s) (format: %s, encrypted: %v, secrets: %v)",
+		len(connections), exportFormat(req.Format), doc.Encryption != nil, req.IncludeSecrets)
+	return encoded, nil
+}
+
+// ImportConfigs creates the connections of an export document. Connections whose name
+// already exists are skipped, overwritten or saved under a new name, per the conflict policy.
+func (s *ConfigService) ImportConfigs(req *models.ImportConfigsRequest) (*models.ImportConfigsResult, error) {
+	if err := models.ValidateConflictPolicy(req.ConflictPolicy); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	policy := req.ConflictPolicy
+	if policy == "" {
+		policy = models.ImportConflictSkip
+	}
+
+	doc, err := decodeExport(req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := doc.Connections
+	if doc.Encryption != nil {
+		if connections, err = openConnections(doc, derefString(req.Passphrase)); err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := s.repo.GetAll(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configs: %w", err)
+	}
+	byName := make(map[string]*models.Config, len(existing))
+	hasDefault := false
+	for _, config := range existing {
+		byName[config.ConnectionName] = config
+		hasDefault = hasDefault || config.SetAsDefault
+	}
+
+	result := &models.ImportConfigsResult{Items: []models.ImportedConfig{}}
+	for i := range connections {
+		conn := connections[i]
+		conn.ConnectionName = strings.TrimSpace(conn.ConnectionName)
+		item := models.ImportedConfig{Name: conn.ConnectionName}
+
+		// The existing default wins over a default in the file
+		if conn.SetAsDefault && hasDefault {
+			conn.SetAsDefault = false
+		}
+
+		var saved *models.Config
+		var err error
+		current, clash := byName[conn.ConnectionName]
+		switch {
+		case conn.UsesCredentialReference():
+			// A crafted file must not be able to make the app run commands, or read a local
+			// secret and send it to a host of the file's choosing on the next health check
+			err = ErrImportCredentialReference
+		case !clash:
+			item.Action = models.ImportActionCreated
+			saved, err = s.CreateConfig(&conn)
+		case policy == models.ImportConflictSkip:
+			item.Action = models.ImportActionSkipped
+			item.ConfigID = current.ID
+		case policy == models.ImportConflictOverwrite:
+			item.Action = models.ImportActionOverwritten
+			if err = conn.Validate(); err == nil {
+				saved, err = s.UpdateConfig(current.ID, conn.ToOverwrite())
+			}
+		default:
+			item.Action = models.ImportActionRenamed
+			item.OriginalName = conn.ConnectionName
+			conn.ConnectionName = uniqueConnectionName(conn.ConnectionName, byName)
+			item.Name = conn.ConnectionName
+			saved, err = s.CreateConfig(&conn)
+		}
+
+		if err != nil {
+			logging.Warnf("⚠️ Failed to import connection %q: %v", item.Name, err)
+			item.Action = models.ImportActionFailed
+			item.Error = err.Error()
+		} else if saved != nil {
+			item.ConfigID = saved.ID
+			byName[saved.ConnectionName] = saved
+			hasDefault = hasDefault || saved.SetAsDefault
+		}
+
+		switch item.Action {
+		case models.ImportActionCreated:
+			result.Created++
+		case models.ImportActionOverwritten:
+			result.Overwritten++
+		case models.ImportActionRenamed:
+			result.Renamed++
+		case models.ImportActionSkipped:
+			result.Skipped++
+		case models.ImportActionFailed:
+			result.Failed++
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	logging.Infof("📥 Imported connections: %d created, %d overwritten, %d renamed, %d skipped, %d failed",
+		result.Created, result.Overwritten, result.Renamed, result.Skipped, result.Failed)
+	return result, nil
+}
+
+// uniqueConnectionName appends " (2)", " (3)", ... until the name is not taken
+func uniqueConnectionName(name string, taken map[string]*models.Config) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if _, ok := taken[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// sealConnections encrypts the connections with a key derived from the passphrase
+func sealConnections(connections []models.CreateConfigRequest, passphrase string) (*models.ExportEncryption, string, error) {
+	salt := make([]byte, exportSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	cipher, err := exportCipher(passphrase, salt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	plaintext, err := json.Marshal(connections)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode connections: %w", err)
+	}
+	payload, err := cipher.Encrypt(string(plaintext))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encrypt export: %w", err)
+	}
+
+	return &models.ExportEncryption{
+		Algorithm: exportAlgorithm,
+		KDF:       exportKDF,
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+	}, payload, nil
+}
+
+// openConnections decrypts the connections of an encrypted export
+func openConnections(doc *models.ConfigExportDocument, passphrase string) ([]models.CreateConfigRequest, error) {
+	if doc.Encryption.Algorithm != exportAlgorithm || doc.Encryption.KDF != exportKDF {
+		return nil, fmt.Errorf("%w: unsupported encryption %s/%s", ErrInvalidExport, doc.Encryption.Algorithm, doc.Encryption.KDF)
+	}
+	if passphrase == "" {
+		return nil, ErrExportPassphraseRequired
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(doc.Encryption.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid salt", ErrInvalidExport)
+	}
+	cipher, err := exportCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := cipher.Decrypt(doc.Payload)
+	if err != nil || !secrets.IsEncrypted(doc.Payload) {
+		return nil, ErrExportWrongPassphrase
+	}
+
+	var connections []models.CreateConfigRequest
+	if err := json.Unmarshal([]byte(plaintext), &connections); err != nil {
+		return nil, fmt.Errorf("%w: invalid payload", ErrInvalidExport)
+	}
+	return connections, nil
+}
+
+// exportCipher derives the export cipher from a passphrase and salt
+func exportCipher(passphrase string, salt []byte) (*secrets.Cipher, error) {
+	key, err := secrets.DeriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.NewCipher(key)
+}
+
+// exportFormat returns the format to write, defaulting to JSON
+func exportFormat(format string) string {
+	if format == "" {
+		return models.ExportFormatJSON
+	}
+	return format
+}
+
+// encodeExport writes the document as indented JSON, or as YAML with the same keys in the same order
+func encodeExport(doc *models.ConfigExportDocument, format string) (string, error) {
+	encoded, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode export: %w", err)
+	}
+	if exportFormat(format) == models.ExportFormatJSON {
+		return string(encoded), nil
+	}
+
+	// JSON is valid YAML, so parsing it keeps the key order of the JSON tags
+	var node yaml.Node
+	if err := yaml.Unmarshal(encoded, &node); err != nil {
+		return "", fmt.Errorf("failed to encode export: %w", err)
+	}
+	clearYAMLStyle(&node)
+
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode export: %w", err)
+	}
+	return string(out), nil
+}
+
+// clearYAMLStyle drops the flow and quoting styles taken over from JSON, so the
+// document is written in block style with quotes only where YAML needs them
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+// decodeExport reads a JSON or YAML export document and checks its kind and version
+func decodeExport(data string) (*models.ConfigExportDocument, error) {
+	raw := []byte(strings.TrimSpace(data))
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidExport)
+	}
+
+	if !json.Valid(raw) {
+		var value interface{}
+		if err := yaml.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		converted, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		raw = converted
+	}
+
+	var doc models.ConfigExportDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	if doc.Kind != models.ConfigExportKind {
+		return nil, ErrInvalidExport
+	}
+	if doc.Version < 1 || doc.Version > models.ConfigExportVersion {
+		return nil, fmt.Errorf("%w (version %d)", ErrUnsupportedExportVersion, doc.Version)
+	}
+	return &doc, nil
+}
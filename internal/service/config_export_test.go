@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"elasticgaze/internal/models"
+)
+
+func exportTestConnections() []models.CreateConfigRequest {
+	return []models.CreateConfigRequest{
+		{
+			ConnectionName:       "production",
+			Host:                 "es.example.com",
+			Port:                 "9243",
+			SSLOrHTTPS:           true,
+			AuthenticationMethod: "basic",
+			Username:             models.StringPtr("elastic"),
+			Password:             models.StringPtr("changeme"),
+			DefaultHeaders:       []models.HTTPHeader{{Name: "X-Tenant", Value: "acme"}},
+		},
+		{ConnectionName: "local", Host: "localhost", Port: "9200", AuthenticationMethod: "none"},
+	}
+}
+
+func TestSealOpenConnections(t *testing.T) {
+	connections := exportTestConnections()
+
+	encryption, payload, err := sealConnections(connections, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(payload, "changeme") || strings.Contains(payload, "es.example.com") {
+		t.Fatal("sealed payload contains plaintext")
+	}
+
+	doc := &models.ConfigExportDocument{
+		Kind:       models.ConfigExportKind,
+		Version:    models.ConfigExportVersion,
+		Encryption: encryption,
+		Payload:    payload,
+	}
+
+	opened, err := openConnections(doc, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opened, connections) {
+		t.Errorf("openConnections = %+v, want %+v", opened, connections)
+	}
+
+	if _, err := openConnections(doc, "wrong horse"); !errors.Is(err, ErrExportWrongPassphrase) {
+		t.Errorf("wrong passphrase: err = %v, want ErrExportWrongPassphrase", err)
+	}
+	if _, err := openConnections(doc, ""); !errors.Is(err, ErrExportPassphraseRequired) {
+		t.Errorf("no passphrase: err = %v, want ErrExportPassphraseRequired", err)
+	}
+
+	doc.Encryption.KDF = "pbkdf2"
+	if _, err := openConnections(doc, "correct horse"); !errors.Is(err, ErrInvalidExport) {
+		t.Errorf("unknown KDF: err = %v, want ErrInvalidExport", err)
+	}
+}
+
+func TestEncodeDecodeExport(t *testing.T) {
+	encryption, payload, err := sealConnections(exportTestConnections(), "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{models.ExportFormatJSON, models.ExportFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			doc := &models.ConfigExportDocument{
+				Kind:            models.ConfigExportKind,
+				Version:         models.ConfigExportVersion,
+				ExportedAt:      "2026-01-02T03:04:05Z",
+				IncludesSecrets: true,
+				Encryption:      encryption,
+				Payload:         payload,
+			}
+
+			encoded, err := encodeExport(doc, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := decodeExport(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, doc) {
+				t.Errorf("decodeExport = %+v, want %+v", decoded, doc)
+			}
+
+			opened, err := openConnections(decoded, "correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(opened, exportTestConnections()) {
+				t.Errorf("openConnections after %s round trip = %+v", format, opened)
+			}
+		})
+	}
+}
+
+func TestDecodeExportRejectsOtherDocuments(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"empty", "  ", ErrInvalidExport},
+		{"other kind", `{"kind":"something/else","version":1}`, ErrInvalidExport},
+		{"newer version", `{"kind":"` + models.ConfigExportKind + `","version":99}`, ErrUnsupportedExportVersion},
+		{"not a document", "- just\n- a list\n", ErrInvalidExport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeExport(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("decodeExport: err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,19 +2,26 @@ package service
 
 import (
 	"fmt"
+	"strings"
 
+	"elasticgaze/internal/logging"
 	"elasticgaze/internal/models"
 	"elasticgaze/internal/repository"
 )
 
+// defaultConnectionSettingsKey is the app setting holding the default connection policy
+const defaultConnectionSettingsKey = "default_connection"
+
 // ConfigService handles business logic for configuration operations
 type ConfigService struct {
-	repo *repository.ConfigRepository
+	repo         *repository.ConfigRepository
+	settingsRepo *repository.SettingsRepository
 }
 
-// NewConfigService creates a new config service
-func NewConfigService(repo *repository.ConfigRepository) *ConfigService {
-	return &ConfigService{repo: repo}
+// NewConfigService creates a new config service. Without a settings repository the
+// default connection settings are fixed to their defaults.
+func NewConfigService(repo *repository.ConfigRepository, settingsRepo *repository.SettingsRepository) *ConfigService {
+	return &ConfigService{repo: repo, settingsRepo: settingsRepo}
 }
 
 // CreateConfig creates a new configuration
@@ -24,18 +31,27 @@ func (s *ConfigService) CreateConfig(req *models.CreateConfigRequest) (*models.C
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
-	// Check if trying to create a default connection when one already exists
-	if req.SetAsDefault {
-		hasDefault, err := s.repo.HasDefaultConfig()
+	// A Cloud ID replaces the host, port and scheme entered in the form
+	if req.CloudID != nil && *req.CloudID != "" {
+		endpoint, err := decodeCloudID(*req.CloudID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to check for existing default: %w", err)
-		}
-		if hasDefault {
-			return nil, models.ErrMultipleDefaultsNotAllowed
+			return nil, fmt.Errorf("validation error: %w (%v)", models.ErrInvalidCloudID, err)
 		}
+		req.Host = endpoint.Host
+		req.Port = endpoint.Port
+		req.SSLOrHTTPS = true
+	}
+
+	if req.SafetyMode == "" {
+		req.SafetyMode = models.SafetyModeUnrestricted
 	}
 
-	// Create the configuration
+	req.Tags = models.NormalizeTags(req.Tags)
+	if err := s.checkGroupExists(req.GroupID); err != nil {
+		return nil, err
+	}
+
+	// Create the configuration. A new default replaces the previous one in the same transaction.
 	config, err := s.repo.Create(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create config: %w", err)
@@ -58,9 +74,16 @@ func (s *ConfigService) GetConfigByID(id int) (*models.Config, error) {
 	return config, nil
 }
 
-// GetAllConfigs retrieves all configurations
-func (s *ConfigService) GetAllConfigs() ([]*models.Config, error) {
-	configs, err := s.repo.GetAll()
+// GetAllConfigs retrieves the configurations matching the filter, or all of them when filter is nil
+func (s *ConfigService) GetAllConfigs(filter *models.ConfigFilter) ([]*models.Config, error) {
+	if filter != nil {
+		if err := filter.Validate(); err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+		filter.Tags = models.NormalizeTags(filter.Tags)
+	}
+
+	configs, err := s.repo.GetAll(filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all configs: %w", err)
 	}
@@ -90,20 +113,50 @@ func (s *ConfigService) UpdateConfig(id int, req *models.UpdateConfigRequest) (*
 		return nil, fmt.Errorf("config not found: %w", err)
 	}
 
-	// Check if trying to set as default when another default already exists
-	if req.SetAsDefault != nil && *req.SetAsDefault {
-		// Only validate if this config is not already the default
-		if !existingConfig.SetAsDefault {
-			hasDefault, err := s.repo.HasDefaultConfig()
-			if err != nil {
-				return nil, fmt.Errorf("failed to check for existing default: %w", err)
-			}
-			if hasDefault {
-				return nil, models.ErrMultipleDefaultsNotAllowed
-			}
+	// Secrets sent back unchanged from a redacted read keep their stored values
+	req.DropRedactedSecrets(existingConfig)
+
+	if req.ProxyURL != nil && *req.ProxyURL != "" {
+		if _, err := models.ParseProxyURL(*req.ProxyURL); err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
 		}
 	}
+	if err := models.ValidateHeaders(req.DefaultHeaders); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if err := models.ValidateAWSService(req.AWSService); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if err := models.ValidateTimeouts(req.ConnectTimeoutSeconds, req.TLSTimeoutSeconds, req.ResponseTimeoutSeconds, req.MaxRetries); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if req.SafetyMode != nil {
+		if *req.SafetyMode == "" {
+			*req.SafetyMode = models.SafetyModeUnrestricted
+		}
+		if err := models.ValidateSafetyMode(*req.SafetyMode); err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+	}
+	req.Tags = models.NormalizeTags(req.Tags)
+	if err := s.checkGroupExists(req.GroupID); err != nil {
+		return nil, err
+	}
 
+	// A new Cloud ID replaces the stored host, port and scheme
+	if req.CloudID != nil && *req.CloudID != "" {
+		endpoint, err := decodeCloudID(*req.CloudID)
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w (%v)", models.ErrInvalidCloudID, err)
+		}
+		https := true
+		req.Host = &endpoint.Host
+		req.Port = &endpoint.Port
+		req.SSLOrHTTPS = &https
+	}
+
+	// Setting the default replaces the previous one in the same transaction
 	config, err := s.repo.Update(id, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update config: %w", err)
@@ -112,32 +165,88 @@ func (s *ConfigService) UpdateConfig(id int, req *models.UpdateConfigRequest) (*
 	return config, nil
 }
 
-// DeleteConfig deletes a configuration by ID
-func (s *ConfigService) DeleteConfig(id int) error {
+// UpdateClusterProfile records the cluster profile detected when connecting
+func (s *ConfigService) UpdateClusterProfile(id int, profile *models.ClusterProfile) error {
 	if id <= 0 {
 		return fmt.Errorf("invalid ID: must be greater than 0")
 	}
+	if profile == nil {
+		return nil
+	}
 
-	// Check if this is the default config and if there are other configs
-	config, err := s.repo.GetByID(id)
-	if err != nil {
-		return fmt.Errorf("config not found: %w", err)
+	if err := s.repo.UpdateClusterProfile(id, profile); err != nil {
+		return fmt.Errorf("failed to store cluster profile: %w", err)
 	}
+	return nil
+}
 
-	// If this is the default config, we might want to handle this specially
-	if config.SetAsDefault {
-		// You could either prevent deletion or automatically set another config as default
-		// For now, we'll allow deletion but you might want to add business logic here
+// DeleteConfig deletes a configuration by ID. Deleting the default applies the
+// configured policy, promoting the most recently used connection unless set to "none".
+func (s *ConfigService) DeleteConfig(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid ID: must be greater than 0")
 	}
 
-	err = s.repo.Delete(id)
+	settings, err := s.GetDefaultConnectionSettings()
+	if err != nil {
+		return err
+	}
+
+	promotedID, err := s.repo.Delete(id, settings.OnDefaultDeleted == models.DefaultOnDeletePromoteRecent)
 	if err != nil {
 		return fmt.Errorf("failed to delete config: %w", err)
 	}
+	if promotedID != nil {
+		logging.Infof("⭐ Deleted default connection %d; connection %d is now the default", id, *promotedID)
+	}
 
 	return nil
 }
 
+// SetDefaultConfig makes a configuration the default, replacing the current default
+func (s *ConfigService) SetDefaultConfig(id int) (*models.Config, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid ID: must be greater than 0")
+	}
+
+	config, err := s.repo.SetDefault(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set default config: %w", err)
+	}
+	return config, nil
+}
+
+// GetDefaultConnectionSettings returns the default connection policy
+func (s *ConfigService) GetDefaultConnectionSettings() (*models.DefaultConnectionSettings, error) {
+	settings := &models.DefaultConnectionSettings{OnDefaultDeleted: models.DefaultOnDeletePromoteRecent}
+	if s.settingsRepo == nil {
+		return settings, nil
+	}
+
+	if _, err := s.settingsRepo.Get(defaultConnectionSettingsKey, settings); err != nil {
+		return nil, err
+	}
+	if settings.Validate() != nil {
+		settings.OnDefaultDeleted = models.DefaultOnDeletePromoteRecent
+	}
+	return settings, nil
+}
+
+// UpdateDefaultConnectionSettings stores the default connection policy
+func (s *ConfigService) UpdateDefaultConnectionSettings(settings *models.DefaultConnectionSettings) (*models.DefaultConnectionSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if s.settingsRepo == nil {
+		return nil, fmt.Errorf("settings storage is not available")
+	}
+
+	if err := s.settingsRepo.Set(defaultConnectionSettingsKey, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
 // HasDefaultConfig checks if there is a default connection configured
 func (s *ConfigService) HasDefaultConfig() (bool, error) {
 	hasDefault, err := s.repo.HasDefaultConfig()
@@ -146,3 +255,104 @@ func (s *ConfigService) HasDefaultConfig() (bool, error) {
 	}
 	return hasDefault, nil
 }
+
+// RecordConfigUsage records that a configuration just executed a request
+func (s *ConfigService) RecordConfigUsage(id int) error {
+	return s.repo.TouchLastUsed(id)
+}
+
+// GetAllConfigTags returns every tag used by a configuration
+func (s *ConfigService) GetAllConfigTags() ([]string, error) {
+	return s.repo.GetAllTags()
+}
+
+// checkGroupExists verifies that a connection is assigned to an existing group; nil and 0 mean no group
+func (s *ConfigService) checkGroupExists(groupID *int) error {
+	if groupID == nil || *groupID == 0 {
+		return nil
+	}
+	if _, err := s.repo.GetGroupByID(*groupID); err != nil {
+		return fmt.Errorf("group not found: %w", err)
+	}
+	return nil
+}
+
+// Connection groups business logic
+
+// CreateConfigGroup creates a new connection group
+func (s *ConfigService) CreateConfigGroup(req *models.CreateConfigGroupRequest) (*models.ConfigGroup, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := s.checkGroupExists(req.ParentGroupID); err != nil {
+		return nil, fmt.Errorf("parent %w", err)
+	}
+
+	return s.repo.CreateGroup(req)
+}
+
+// GetAllConfigGroups retrieves every connection group
+func (s *ConfigService) GetAllConfigGroups() ([]*models.ConfigGroup, error) {
+	return s.repo.GetAllGroups()
+}
+
+// UpdateConfigGroup renames a connection group or moves it under another group
+func (s *ConfigService) UpdateConfigGroup(id int, req *models.UpdateConfigGroupRequest) (*models.ConfigGroup, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid group ID")
+	}
+
+	if _, err := s.repo.GetGroupByID(id); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("validation error: %w", models.ErrGroupNameRequired)
+		}
+		req.Name = &name
+	}
+
+	// A group cannot move under itself or one of its own subgroups
+	if req.ParentGroupID != nil && *req.ParentGroupID != 0 {
+		if *req.ParentGroupID == id {
+			return nil, fmt.Errorf("group cannot be its own parent")
+		}
+		if err := s.checkGroupExists(req.ParentGroupID); err != nil {
+			return nil, fmt.Errorf("parent %w", err)
+		}
+		if s.wouldCreateGroupCycle(id, *req.ParentGroupID) {
+			return nil, fmt.Errorf("operation would create circular reference")
+		}
+	}
+
+	return s.repo.UpdateGroup(id, req)
+}
+
+// DeleteConfigGroup deletes a connection group; its connections and subgroups move to its parent
+func (s *ConfigService) DeleteConfigGroup(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid group ID")
+	}
+	return s.repo.DeleteGroup(id)
+}
+
+// wouldCreateGroupCycle reports whether making parentID the parent of groupID would create a cycle
+func (s *ConfigService) wouldCreateGroupCycle(groupID int, parentID int) bool {
+	currentID := parentID
+	for visited := 0; currentID != 0 && visited < 1000; visited++ {
+		if currentID == groupID {
+			return true
+		}
+
+		parent, err := s.repo.GetGroupByID(currentID)
+		if err != nil || parent.ParentGroupID == nil {
+			break
+		}
+		currentID = *parent.ParentGroupID
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// Notifier shows a desktop notification
+type Notifier func(title, message string) error
+
+// windowsToastScript shows a toast through the WinRT notification API, which is available
+// to PowerShell without extra modules. It is attributed to PowerShell's registered app ID.
+const windowsToastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName('text')
+$texts.Item(0).AppendChild($template.CreateTextNode($env:ELASTICGAZE_NOTIFY_TITLE)) > $null
+$texts.Item(1).AppendChild($template.CreateTextNode($env:ELASTICGAZE_NOTIFY_MESSAGE)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)
+`
+
+// DesktopNotify shows a native notification using the platform's notification tool:
+// a toast on Windows, Notification Center on macOS and notify-send elsewhere. The title
+// and message are passed through the environment so they are never parsed as script.
+func DesktopNotify(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-WindowStyle", "Hidden", "-Command", windowsToastScript)
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript", "-e",
+			`display notification (system attribute "ELASTICGAZE_NOTIFY_MESSAGE") with title (system attribute "ELASTICGAZE_NOTIFY_TITLE")`)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=ElasticGaze", "--", title, message)
+	}
+	cmd.Env = append(os.Environ(), "ELASTICGAZE_NOTIFY_TITLE="+title, "ELASTICGAZE_NOTIFY_MESSAGE="+message)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show desktop notification: %w: %s", err, output)
+	}
+	return nil
+}
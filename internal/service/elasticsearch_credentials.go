@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+const (
+	credentialCacheTTL       = 5 * time.Minute  // How long a resolved reference is reused
+	credentialCommandTimeout = 60 * time.Second // Helper commands may wait for the user to unlock a vault
+)
+
+// credentialCache keeps resolved credential references, keyed by the reference itself.
+// Concurrent resolutions of the same reference share one lookup, so a slow helper
+// command runs once however many requests and health checks need it.
+type credentialCache struct {
+	mu       sync.Mutex
+	entries  map[string]cachedCredential
+	inflight map[string]*credentialLookup
+}
+
+// credentialLookup is a resolution in progress. It is cancelled once every caller
+// waiting for it has given up.
+type credentialLookup struct {
+	done    chan struct{}
+	value   string
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type cachedCredential struct {
+	value   string
+	expires time.Time
+}
+
+func newCredentialCache() *credentialCache {
+	return &credentialCache{
+		entries:  make(map[string]cachedCredential),
+		inflight: make(map[string]*credentialLookup),
+	}
+}
+
+func (c *credentialCache) forget(refs ...*string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ref := range refs {
+		if ref != nil {
+			delete(c.entries, strings.TrimSpace(*ref))
+		}
+	}
+}
+
+// resolve returns the cached value of ref, or runs lookup once for all concurrent callers.
+// A caller whose ctx ends stops waiting; the lookup itself is cancelled when no caller
+// is left.
+func (c *credentialCache) resolve(ctx context.Context, ref string, lookup func(context.Context) (string, error)) (string, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[ref]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+	call, running := c.inflight[ref]
+	if !running {
+		lookupCtx, cancel := context.WithCancel(context.Background())
+		call = &credentialLookup{done: make(chan struct{}), cancel: cancel}
+		c.inflight[ref] = call
+		go c.run(lookupCtx, ref, call, lookup)
+	}
+	call.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+		}
+		c.mu.Unlock()
+		return "", ctx.Err()
+	}
+}
+
+// run performs a lookup started by resolve and caches a successful result
+func (c *credentialCache) run(ctx context.Context, ref string, call *credentialLookup, lookup func(context.Context) (string, error)) {
+	value, err := lookup(ctx)
+	call.cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.inflight, ref)
+	if err == nil {
+		c.entries[ref] = cachedCredential{value: value, expires: time.Now().Add(credentialCacheTTL)}
+	}
+	call.value, call.err = value, err
+	close(call.done)
+}
+
+// credential returns the value of a password, API key or token, resolving it first
+// when it is a ${env:...}, ${file:...} or ${cmd:...} reference. Resolution stops when
+// ctx ends, e.g. when the request is cancelled.
+func (s *ElasticsearchService) credential(ctx context.Context, value string) (string, error) {
+	kind, target, ok := models.ParseCredentialReference(value)
+	if !ok {
+		return value, nil
+	}
+
+	return s.credentials.resolve(ctx, strings.TrimSpace(value), func(ctx context.Context) (string, error) {
+		var resolved string
+		var err error
+		switch kind {
+		case models.CredentialRefEnv:
+			resolved, err = credentialFromEnv(target)
+		case models.CredentialRefFile:
+			resolved, err = credentialFromFile(target)
+		case models.CredentialRefCmd:
+			resolved, err = credentialFromCommand(ctx, target)
+		}
+		if err != nil {
+			return "", err
+		}
+		if resolved == "" {
+			return "", fmt.Errorf("credential reference %s resolved to an empty value", kind)
+		}
+		return resolved, nil
+	})
+}
+
+// forgetCredentials drops a connection's cached references, e.g. after Elasticsearch
+// rejects them, so the next request resolves a possibly rotated secret
+func (s *ElasticsearchService) forgetCredentials(connReq *models.TestConnectionRequest) {
+	s.credentials.forget(connReq.Password, connReq.APIKey, connReq.BearerToken)
+}
+
+func credentialFromEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+func credentialFromFile(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// credentialFromCommand runs a helper command through the system shell and returns
+// its stdout without the trailing newline, like git credential helpers. The command is
+// killed when ctx ends.
+func credentialFromCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second // Don't wait on children of the shell that hold stdout open
+
+	logging.Info("🔑 Resolving credential with helper command")
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("credential helper timed out after %v", credentialCommandTimeout)
+		}
+		return "", fmt.Errorf("credential helper failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
@@ -0,0 +1,640 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+const (
+	certificateExpiryWarning = 30 * 24 * time.Hour // Certificates expiring sooner are reported as a warning
+	diagnosticBodyLimit      = 1 << 20             // Diagnostic responses are small; don't read more than this
+)
+
+// diagnosticTitles are the human readable names of the diagnostic steps
+var diagnosticTitles = map[string]string{
+	models.DiagnosticStepDNS:     "DNS resolution",
+	models.DiagnosticStepTCP:     "TCP connect",
+	models.DiagnosticStepTLS:     "TLS handshake",
+	models.DiagnosticStepHTTP:    "HTTP reachability",
+	models.DiagnosticStepAuth:    "Authentication",
+	models.DiagnosticStepCluster: "Cluster info",
+}
+
+// connectionDiagnosis carries what one diagnostic step learns to the next
+type connectionDiagnosis struct {
+	ctx     context.Context
+	s       *ElasticsearchService
+	connReq *models.TestConnectionRequest
+	result  *models.ConnectionDiagnostics
+
+	hopHost string // First host the connection reaches: the cluster, its proxy or its SSH bastion
+	hopPort string
+	hopKind string // "cluster", "proxy" or "SSH bastion"
+
+	conn     net.Conn            // Direct TCP connection to the cluster, handed to the TLS step
+	rootInfo *models.ClusterInfo // Unauthenticated root response, when the cluster allows it
+}
+
+// DiagnoseConnection checks a connection one stage at a time: DNS resolution, TCP
+// connect, TLS handshake, HTTP reachability, authentication and cluster info. Each step
+// reports its duration and outcome, and steps after a failure are skipped, so the user
+// sees where a failing connection breaks instead of a single error.
+func (s *ElasticsearchService) DiagnoseConnection(ctx context.Context, req *models.TestConnectionRequest) (*models.ConnectionDiagnostics, error) {
+	logging.Infof("🩺 Diagnosing Elasticsearch connection to %s:%s", req.Host, req.Port)
+
+	result := &models.ConnectionDiagnostics{}
+	start := time.Now()
+	defer func() {
+		result.TotalMs = time.Since(start).Milliseconds()
+	}()
+
+	if err := applyCloudID(req); err != nil {
+		result.Message = "Invalid Cloud ID"
+		result.ErrorCode = "INVALID_CLOUD_ID"
+		return result, nil
+	}
+	if err := req.Validate(); err != nil {
+		result.Message = fmt.Sprintf("Validation failed: %v", err)
+		result.ErrorCode = "VALIDATION_ERROR"
+		return result, nil
+	}
+
+	d := &connectionDiagnosis{ctx: ctx, s: s, connReq: req, result: result}
+	defer func() {
+		if d.conn != nil {
+			d.conn.Close()
+		}
+	}()
+
+	d.run(models.DiagnosticStepDNS, d.resolveHost)
+	d.run(models.DiagnosticStepTCP, d.connectTCP)
+	d.run(models.DiagnosticStepTLS, d.handshakeTLS)
+	d.run(models.DiagnosticStepHTTP, d.checkHTTP)
+	d.run(models.DiagnosticStepAuth, d.checkAuthentication)
+	d.run(models.DiagnosticStepCluster, d.checkClusterInfo)
+
+	result.Success = result.ErrorCode == ""
+	if result.Success {
+		result.Message = "All checks passed"
+		logging.Info("🎉 Connection diagnosis passed")
+	} else {
+		logging.Warnf("⚠️ Connection diagnosis failed: %s", result.Message)
+	}
+	return result, nil
+}
+
+// run times a step and records it, skipping it when an earlier step failed
+func (d *connectionDiagnosis) run(name string, check func(step *models.DiagnosticStep)) {
+	step := &models.DiagnosticStep{Name: name, Title: diagnosticTitles[name]}
+	d.result.Steps = append(d.result.Steps, step)
+
+	if d.result.ErrorCode != "" {
+		step.Status = models.DiagnosticSkipped
+		step.Message = "Skipped because an earlier step failed"
+		return
+	}
+
+	start := time.Now()
+	check(step)
+	step.DurationMs = time.Since(start).Milliseconds()
+
+	switch step.Status {
+	case models.DiagnosticFailed:
+		d.result.ErrorCode = step.ErrorCode
+		d.result.Message = fmt.Sprintf("%s failed: %s", step.Title, step.Message)
+		logging.Errorf("❌ %s failed after %dms: %s", step.Title, step.DurationMs, step.Message)
+	case models.DiagnosticWarning:
+		logging.Warnf("⚠️ %s passed with a warning after %dms: %s", step.Title, step.DurationMs, step.Message)
+	case models.DiagnosticSkipped:
+		logging.Infof("⏭️ %s skipped: %s", step.Title, step.Message)
+	default:
+		logging.Infof("✅ %s passed in %dms: %s", step.Title, step.DurationMs, step.Message)
+	}
+}
+
+// failStep marks a step as failed
+func failStep(step *models.DiagnosticStep, code, message string, err error) {
+	step.Status = models.DiagnosticFailed
+	step.ErrorCode = code
+	step.Message = message
+	if err != nil {
+		step.ErrorDetails = err.Error()
+	}
+}
+
+// resolveHost resolves the first hop of the connection and reports every address
+func (d *connectionDiagnosis) resolveHost(step *models.DiagnosticStep) {
+	if err := d.firstHop(); err != nil {
+		code, message := classifyConnectionError(err)
+		failStep(step, code, message, err)
+		return
+	}
+	step.Target = d.hopHost
+
+	if ip := net.ParseIP(d.hopHost); ip != nil {
+		step.Status = models.DiagnosticPassed
+		step.Addresses = []string{ip.String()}
+		step.Message = fmt.Sprintf("The %s host is an IP address; no lookup needed", d.hopKind)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, connectTimeout(d.connReq))
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupHost(ctx, d.hopHost)
+	if err != nil {
+		var dnsErr *net.DNSError
+		switch {
+		case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+			failStep(step, "DNS_NOT_FOUND", fmt.Sprintf("The %s host name does not exist", d.hopKind), err)
+		case errors.As(err, &dnsErr) && dnsErr.IsTimeout:
+			failStep(step, "DNS_TIMEOUT", "DNS lookup timed out", err)
+		default:
+			failStep(step, "DNS_ERROR", fmt.Sprintf("The %s host name could not be resolved", d.hopKind), err)
+		}
+		return
+	}
+
+	step.Status = models.DiagnosticPassed
+	step.Addresses = addresses
+	step.Message = fmt.Sprintf("Resolved the %s host to %d address(es)", d.hopKind, len(addresses))
+}
+
+// firstHop works out which host the connection reaches first: the SSH bastion, the
+// proxy unless the cluster is listed in no_proxy, or the cluster itself
+func (d *connectionDiagnosis) firstHop() error {
+	d.hopHost, d.hopPort, d.hopKind = d.connReq.Host, d.connReq.Port, "cluster"
+
+	if settings := sshSettingsFor(d.connReq); settings != nil {
+		host, port, err := net.SplitHostPort(settings.address)
+		if err != nil {
+			return &sshTunnelError{err: err}
+		}
+		d.hopHost, d.hopPort, d.hopKind = host, port, "SSH bastion"
+		return nil
+	}
+
+	proxy, err := buildProxyFunc(d.connReq)
+	if err != nil {
+		return &proxyConfigError{err: err}
+	}
+	if proxy == nil {
+		return nil
+	}
+	target, _ := url.Parse(d.s.buildURL(d.connReq, "/"))
+	proxyURL, err := proxy(&http.Request{URL: target})
+	if err != nil || proxyURL == nil {
+		return err
+	}
+
+	port := proxyURL.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[proxyURL.Scheme]
+		if port == "" {
+			port = "1080" // SOCKS5
+		}
+	}
+	d.hopHost, d.hopPort, d.hopKind = proxyURL.Hostname(), port, "proxy"
+	return nil
+}
+
+// connectTCP opens a TCP connection to the first hop
+func (d *connectionDiagnosis) connectTCP(step *models.DiagnosticStep) {
+	address := net.JoinHostPort(d.hopHost, d.hopPort)
+	step.Target = address
+
+	dialer := &net.Dialer{Timeout: connectTimeout(d.connReq)}
+	conn, err := dialer.DialContext(d.ctx, "tcp", address)
+	if err != nil {
+		code, message := classifyConnectionError(err)
+		failStep(step, code, fmt.Sprintf("%s to the %s at %s", message, d.hopKind, address), err)
+		return
+	}
+
+	step.Status = models.DiagnosticPassed
+	step.Message = fmt.Sprintf("Connected to the %s at %s", d.hopKind, conn.RemoteAddr())
+	if d.hopKind == "cluster" {
+		d.conn = conn // Reused for the TLS handshake
+	} else {
+		conn.Close()
+	}
+}
+
+// handshakeTLS performs the TLS handshake with the cluster using the connection's TLS
+// settings and reports the negotiated session and the presented certificate chain,
+// including when verification fails
+func (d *connectionDiagnosis) handshakeTLS(step *models.DiagnosticStep) {
+	step.Target = net.JoinHostPort(d.connReq.Host, d.connReq.Port)
+
+	switch {
+	case !d.connReq.SSLOrHTTPS:
+		step.Status = models.DiagnosticSkipped
+		step.Message = "The connection uses plain HTTP"
+		return
+	case d.hopKind == "proxy":
+		step.Status = models.DiagnosticSkipped
+		step.Message = "TLS is negotiated through the proxy and checked by the HTTP step"
+		return
+	}
+
+	tlsConfig, err := buildTLSConfig(d.connReq)
+	if err != nil {
+		failStep(step, "TLS_CONFIG_ERROR", "Invalid TLS configuration", err)
+		return
+	}
+
+	conn := d.conn
+	d.conn = nil
+	if conn == nil {
+		// Reach the cluster through the SSH tunnel, as requests do
+		ctx, cancel := context.WithTimeout(d.ctx, connectTimeout(d.connReq))
+		conn, err = d.s.tunnelDialer(d.connReq)(ctx, "tcp", step.Target)
+		cancel()
+		if err != nil {
+			code, message := classifyConnectionError(err)
+			failStep(step, code, message, err)
+			return
+		}
+	}
+	defer conn.Close()
+
+	details := &models.TLSDiagnostics{ServerName: d.connReq.Host, Verification: tlsVerificationMode(tlsConfig)}
+	step.TLS = details
+
+	// Verify in VerifyConnection rather than in the handshake itself, so the chain can
+	// be reported even when the server's certificate is rejected
+	var state *tls.ConnectionState
+	inspect := tlsConfig.Clone()
+	inspect.ServerName = d.connReq.Host
+	inspect.InsecureSkipVerify = true
+	inspect.VerifyConnection = func(cs tls.ConnectionState) error {
+		state = &cs
+		switch details.Verification {
+		case models.TLSVerificationFingerprint:
+			return tlsConfig.VerifyConnection(cs)
+		case models.TLSVerificationChain:
+			return verifyCertificateChain(cs.PeerCertificates, tlsConfig.RootCAs, d.connReq.Host)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, tlsTimeout(d.connReq))
+	defer cancel()
+	handshakeErr := tls.Client(conn, inspect).HandshakeContext(ctx)
+
+	now := time.Now()
+	if state != nil {
+		details.Version = tls.VersionName(state.Version)
+		details.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+		for _, cert := range state.PeerCertificates {
+			details.Certificates = append(details.Certificates, describeCertificate(cert, now))
+		}
+		if len(state.PeerCertificates) > 0 {
+			details.SANMatch = state.PeerCertificates[0].VerifyHostname(d.connReq.Host) == nil
+		}
+	}
+
+	if handshakeErr != nil {
+		code, message, ok := classifyTLSError(handshakeErr)
+		if !ok {
+			code, message = classifyConnectionError(handshakeErr)
+		}
+		failStep(step, code, message, handshakeErr)
+		return
+	}
+	details.Verified = details.Verification != models.TLSVerificationNone
+
+	step.Status = models.DiagnosticPassed
+	step.Message = fmt.Sprintf("Negotiated %s with %s", details.Version, details.CipherSuite)
+
+	var warnings []string
+	if details.Verification == models.TLSVerificationNone {
+		warnings = append(warnings, "certificate verification is disabled")
+	}
+	if !details.SANMatch {
+		warnings = append(warnings, fmt.Sprintf("the certificate is not valid for %s", d.connReq.Host))
+	}
+	for _, cert := range details.Certificates {
+		if cert.Expired {
+			warnings = append(warnings, fmt.Sprintf("certificate %q has expired", cert.Subject))
+		} else if expiry, err := time.Parse(time.RFC3339, cert.NotAfter); err == nil && expiry.Sub(now) < certificateExpiryWarning {
+			warnings = append(warnings, fmt.Sprintf("certificate %q expires in %d day(s)", cert.Subject, cert.DaysUntilExpiry))
+		}
+	}
+	if len(warnings) > 0 {
+		step.Status = models.DiagnosticWarning
+		step.Message += "; " + strings.Join(warnings, "; ")
+	}
+}
+
+// tlsVerificationMode reports how buildTLSConfig verifies the server
+func tlsVerificationMode(tlsConfig *tls.Config) string {
+	switch {
+	case tlsConfig.VerifyConnection != nil:
+		return models.TLSVerificationFingerprint
+	case tlsConfig.InsecureSkipVerify:
+		return models.TLSVerificationNone
+	}
+	return models.TLSVerificationChain
+}
+
+// verifyCertificateChain performs the verification crypto/tls does by default
+func verifyCertificateChain(certs []*x509.Certificate, roots *x509.CertPool, host string) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("tls: server presented no certificates")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       host,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// describeCertificate summarizes a certificate for the diagnostics report
+func describeCertificate(cert *x509.Certificate, now time.Time) *models.CertificateInfo {
+	sum := sha256.Sum256(cert.Raw)
+	info := &models.CertificateInfo{
+		Subject:         cert.Subject.String(),
+		Issuer:          cert.Issuer.String(),
+		DNSNames:        cert.DNSNames,
+		NotBefore:       cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:        cert.NotAfter.UTC().Format(time.RFC3339),
+		DaysUntilExpiry: int(cert.NotAfter.Sub(now).Hours() / 24),
+		Expired:         now.After(cert.NotAfter),
+		IsCA:            cert.IsCA,
+		Fingerprint:     hex.EncodeToString(sum[:]),
+	}
+	for _, ip := range cert.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	return info
+}
+
+// checkHTTP sends an unauthenticated request to the configured host, through the
+// connection's proxy or tunnel, to show the cluster answers HTTP at all
+func (d *connectionDiagnosis) checkHTTP(step *models.DiagnosticStep) {
+	requestURL := d.s.buildURL(d.connReq, "/")
+	step.Target = requestURL
+
+	client, err := d.s.clientFor(d.connReq)
+	if err != nil {
+		code, message := classifyConnectionError(err)
+		failStep(step, code, message, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, responseTimeout(d.connReq))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		failStep(step, "REQUEST_CREATION_ERROR", "Failed to create request", err)
+		return
+	}
+	applyDefaultHeaders(req, d.connReq)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		code, message := classifyConnectionError(err)
+		failStep(step, code, message, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, diagnosticBodyLimit))
+	if err != nil {
+		code, _ := classifyConnectionError(err)
+		failStep(step, code, "Failed to read response", err)
+		return
+	}
+	step.StatusCode = resp.StatusCode
+
+	switch {
+	case resp.StatusCode == http.StatusProxyAuthRequired:
+		failStep(step, "PROXY_AUTH_FAILED", "Proxy authentication failed", nil)
+	case resp.StatusCode >= 500:
+		failStep(step, fmt.Sprintf("HTTP_%d", resp.StatusCode), fmt.Sprintf("The server answered HTTP %d", resp.StatusCode), errors.New(truncate(string(body), 512)))
+	case resp.StatusCode == http.StatusNotFound:
+		step.Status = models.DiagnosticWarning
+		step.Message = "The server answered HTTP 404; check the host and path prefix"
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		step.Status = models.DiagnosticPassed
+		step.Message = fmt.Sprintf("The server answered HTTP %d and requires credentials", resp.StatusCode)
+	default:
+		step.Status = models.DiagnosticPassed
+		step.Message = fmt.Sprintf("The server answered HTTP %d", resp.StatusCode)
+		var info models.ClusterInfo
+		if resp.StatusCode == http.StatusOK && json.Unmarshal(body, &info) == nil && info.Version.Number != "" {
+			d.rootInfo = &info
+		}
+	}
+}
+
+// checkAuthentication asks the cluster which user the configured credentials resolve to
+func (d *connectionDiagnosis) checkAuthentication(step *models.DiagnosticStep) {
+	if d.connReq.AuthenticationMethod == "none" {
+		step.Status = models.DiagnosticSkipped
+		step.Message = "The connection does not use authentication"
+		return
+	}
+
+	profile := d.connReq.ClusterProfile
+	if profile == nil && d.rootInfo != nil {
+		profile = models.NewClusterProfile(d.rootInfo)
+	}
+
+	path := profile.AuthenticatePath()
+	status, body, err := d.authenticatedGet(path)
+	if err == nil && status == http.StatusNotFound && profile == nil {
+		// Not profiled yet and not Elasticsearch's endpoint; try OpenSearch's
+		openSearch := &models.ClusterProfile{Distribution: models.DistributionOpenSearch}
+		path = openSearch.AuthenticatePath()
+		status, body, err = d.authenticatedGet(path)
+	}
+	step.Target = path
+
+	var setupErr *authSetupError
+	switch {
+	case errors.As(err, &setupErr):
+		failStep(step, "AUTH_ERROR", "Authentication setup failed", err)
+		return
+	case err != nil:
+		code, message := classifyConnectionError(err)
+		failStep(step, code, message, err)
+		return
+	case status == http.StatusUnauthorized:
+		failStep(step, "AUTH_FAILED", "The cluster rejected the credentials", errors.New(truncate(body, 512)))
+		return
+	case status == http.StatusForbidden:
+		failStep(step, "ACCESS_FORBIDDEN", "The user may not read its own authentication details", errors.New(truncate(body, 512)))
+		return
+	case status != http.StatusOK:
+		step.Status = models.DiagnosticWarning
+		step.Message = fmt.Sprintf("The cluster did not report the authenticated user (HTTP %d); security may be disabled", status)
+		step.ErrorDetails = truncate(body, 512)
+		return
+	}
+
+	user, err := parseAuthenticatedUser([]byte(body))
+	if err != nil {
+		step.Status = models.DiagnosticWarning
+		step.Message = "Authenticated, but the user details could not be parsed"
+		step.ErrorDetails = err.Error()
+		return
+	}
+	step.User = user
+	step.Status = models.DiagnosticPassed
+	step.Message = fmt.Sprintf("Authenticated as %s", user.Username)
+	if len(user.Roles) > 0 {
+		step.Message += fmt.Sprintf(" with roles %s", strings.Join(user.Roles, ", "))
+	}
+}
+
+// parseAuthenticatedUser reads Elasticsearch's _authenticate and OpenSearch's authinfo responses
+func parseAuthenticatedUser(body []byte) (*models.AuthenticatedUser, error) {
+	var response struct {
+		Username           string   `json:"username"`  // Elasticsearch
+		UserName           string   `json:"user_name"` // OpenSearch
+		Roles              []string `json:"roles"`
+		BackendRoles       []string `json:"backend_roles"`
+		AuthenticationType string   `json:"authentication_type"`
+		Realm              struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"authentication_realm"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	user := &models.AuthenticatedUser{
+		Username:           response.Username,
+		Roles:              response.Roles,
+		BackendRoles:       response.BackendRoles,
+		AuthenticationType: response.AuthenticationType,
+	}
+	if user.Username == "" {
+		user.Username = response.UserName
+	}
+	if user.Username == "" {
+		return nil, fmt.Errorf("response does not name a user")
+	}
+	if response.Realm.Name != "" {
+		user.Realm = fmt.Sprintf("%s (%s)", response.Realm.Name, response.Realm.Type)
+	}
+	if user.Roles == nil {
+		user.Roles = []string{}
+	}
+	return user, nil
+}
+
+// checkClusterInfo reads the root endpoint with the connection's credentials
+func (d *connectionDiagnosis) checkClusterInfo(step *models.DiagnosticStep) {
+	step.Target = "/"
+
+	status, body, err := d.authenticatedGet("/")
+	var setupErr *authSetupError
+	switch {
+	case errors.As(err, &setupErr):
+		failStep(step, "AUTH_ERROR", "Authentication setup failed", err)
+		return
+	case err != nil:
+		code, message := classifyConnectionError(err)
+		failStep(step, code, message, err)
+		return
+	case status == http.StatusUnauthorized:
+		failStep(step, "AUTH_FAILED", "Authentication failed", errors.New(truncate(body, 512)))
+		return
+	case status == http.StatusForbidden:
+		failStep(step, "ACCESS_FORBIDDEN", "The user may not read cluster info", errors.New(truncate(body, 512)))
+		return
+	case status != http.StatusOK:
+		failStep(step, fmt.Sprintf("HTTP_%d", status), fmt.Sprintf("The cluster answered HTTP %d", status), errors.New(truncate(body, 512)))
+		return
+	}
+
+	var info models.ClusterInfo
+	if err := json.Unmarshal([]byte(body), &info); err != nil || info.Version.Number == "" {
+		step.Status = models.DiagnosticWarning
+		step.ErrorCode = "PARSE_WARNING"
+		step.Message = "Connected, but the response does not look like Elasticsearch"
+		step.ErrorDetails = truncate(body, 512)
+		return
+	}
+
+	profile := models.NewClusterProfile(&info)
+	d.result.ClusterName = info.ClusterName
+	d.result.Version = info.Version.Number
+	d.result.Profile = profile
+
+	step.Status = models.DiagnosticPassed
+	step.Message = fmt.Sprintf("Cluster %q runs %s %s", info.ClusterName, profile.Distribution, info.Version.Number)
+	if profile.BuildFlavor != "" {
+		step.Message += fmt.Sprintf(" (%s)", profile.BuildFlavor)
+	}
+}
+
+// authSetupError marks a failure to add credentials to a diagnostic request
+type authSetupError struct {
+	err error
+}
+
+func (e *authSetupError) Error() string {
+	return e.err.Error()
+}
+
+func (e *authSetupError) Unwrap() error {
+	return e.err
+}
+
+// authenticatedGet sends an authenticated GET the way every other request is sent and
+// returns the status and body
+func (d *connectionDiagnosis) authenticatedGet(endpoint string) (int, string, error) {
+	req, err := http.NewRequestWithContext(d.ctx, "GET", d.s.buildURL(d.connReq, endpoint), nil)
+	if err != nil {
+		return 0, "", err
+	}
+	if err := d.s.addAuthentication(req, d.connReq); err != nil {
+		return 0, "", &authSetupError{err: err}
+	}
+
+	resp, err := d.s.doRequest(req, d.connReq)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, diagnosticBodyLimit))
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// truncate shortens s to at most n bytes for error details
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+// serverCancelTimeout limits the best-effort cancellation of server-side tasks
+const serverCancelTimeout = 10 * time.Second
+
+// opaqueIDHeader is echoed by Elasticsearch in the task list and slow logs, which lets a
+// running task be traced back to the execution that started it
+const opaqueIDHeader = "X-Opaque-Id"
+
+// ErrExecutionNotFound is returned when cancelling an execution that is not running
+var ErrExecutionNotFound = errors.New("no running request with this execution ID")
+
+// ErrExecutionInUse is returned when starting an execution with the ID of one still running
+var ErrExecutionInUse = errors.New("a request with this execution ID is already running")
+
+type executionIDKey struct{}
+
+// execution is a cancellable call in flight
+type execution struct {
+	info    models.RunningExecution
+	cancel  context.CancelFunc
+	configs []*models.Config // Connections to cancel server-side tasks on
+}
+
+// executionRegistry tracks running executions by ID
+type executionRegistry struct {
+	mu      sync.Mutex
+	running map[string]*execution
+}
+
+func newExecutionRegistry() *executionRegistry {
+	return &executionRegistry{running: make(map[string]*execution)}
+}
+
+// NewExecutionID returns a random ID for StartExecution
+func NewExecutionID() (string, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate execution ID: %w", err)
+	}
+	return "eg-" + hex.EncodeToString(raw), nil
+}
+
+// executionIDFrom returns the execution ID carried by ctx, if any
+func executionIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(executionIDKey{}).(string)
+	return id
+}
+
+// StartExecution registers a cancellable execution and returns the context to run it
+// with. Every request made with that context carries the ID as X-Opaque-Id. An empty
+// executionID is replaced by a generated one. The returned function must be called
+// when the execution finishes.
+func (s *ElasticsearchService) StartExecution(parent context.Context, executionID, description string, configs ...*models.Config) (context.Context, func(), error) {
+	if executionID == "" {
+		id, err := NewExecutionID()
+		if err != nil {
+			return nil, nil, err
+		}
+		executionID = id
+	} else if err := models.ValidateExecutionID(executionID); err != nil {
+		return nil, nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(parent, executionIDKey{}, executionID))
+	exec := &execution{
+		info: models.RunningExecution{
+			ExecutionID: executionID,
+			Description: description,
+			StartedAt:   time.Now().UTC().Format(time.RFC3339),
+		},
+		cancel:  cancel,
+		configs: configs,
+	}
+	for _, config := range configs {
+		exec.info.ConfigIDs = append(exec.info.ConfigIDs, config.ID)
+	}
+
+	s.executions.mu.Lock()
+	if _, running := s.executions.running[executionID]; running {
+		s.executions.mu.Unlock()
+		cancel()
+		return nil, nil, ErrExecutionInUse
+	}
+	s.executions.running[executionID] = exec
+	s.executions.mu.Unlock()
+
+	done := func() {
+		s.executions.mu.Lock()
+		if s.executions.running[executionID] == exec {
+			delete(s.executions.running, executionID)
+		}
+		s.executions.mu.Unlock()
+		cancel()
+	}
+	return ctx, done, nil
+}
+
+// CancelExecution aborts the HTTP calls of a running execution straight away, then asks
+// each of its clusters to cancel the tasks the execution started, in the background.
+// The server-side cancel is best effort: the user may lack the manage privilege, or the
+// request may not have created a task.
+func (s *ElasticsearchService) CancelExecution(executionID string) error {
+	s.executions.mu.Lock()
+	exec, ok := s.executions.running[executionID]
+	if ok {
+		delete(s.executions.running, executionID)
+	}
+	s.executions.mu.Unlock()
+
+	if !ok {
+		return ErrExecutionNotFound
+	}
+
+	logging.Infof("🛑 Cancelling %s (%s)", exec.info.Description, executionID)
+	exec.cancel()
+
+	for _, config := range exec.configs {
+		go func(config *models.Config) {
+			ctx, cancel := context.WithTimeout(context.Background(), serverCancelTimeout)
+			defer cancel()
+
+			cancelled, err := s.cancelServerTasks(ctx, config.ToConnectionRequest(), executionID)
+			switch {
+			case err != nil:
+				logging.Warnf("⚠️ Failed to cancel tasks of %s on %s: %v", executionID, config.ConnectionName, err)
+			case cancelled > 0:
+				logging.Infof("🛑 Cancelled %d task(s) of %s on %s", cancelled, executionID, config.ConnectionName)
+			}
+		}(config)
+	}
+	return nil
+}
+
+// RunningExecutions lists the executions in flight, oldest first
+func (s *ElasticsearchService) RunningExecutions() []models.RunningExecution {
+	s.executions.mu.Lock()
+	defer s.executions.mu.Unlock()
+
+	running := make([]models.RunningExecution, 0, len(s.executions.running))
+	for _, exec := range s.executions.running {
+		running = append(running, exec.info)
+	}
+	sort.Slice(running, func(i, j int) bool {
+		if running[i].StartedAt != running[j].StartedAt {
+			return running[i].StartedAt < running[j].StartedAt
+		}
+		return running[i].ExecutionID < running[j].ExecutionID
+	})
+	return running
+}
+
+// taskList is the part of the _tasks response needed to find an execution's tasks
+type taskList struct {
+	Nodes map[string]struct {
+		Tasks map[string]struct {
+			Cancellable  bool              `json:"cancellable"`
+			ParentTaskID string            `json:"parent_task_id"`
+			Headers      map[string]string `json:"headers"`
+		} `json:"tasks"`
+	} `json:"nodes"`
+}
+
+// cancelServerTasks cancels the cluster tasks started with the given X-Opaque-Id and
+// returns how many were cancelled. Child tasks are cancelled with their parent.
+func (s *ElasticsearchService) cancelServerTasks(ctx context.Context, connReq *models.TestConnectionRequest, opaqueID string) (int, error) {
+	if connReq.ClusterProfile != nil && connReq.ClusterProfile.IsServerless() {
+		return 0, nil // Serverless projects don't expose the task management API
+	}
+
+	body, err := s.getJSON(ctx, connReq, "/_tasks?actions=*")
+	if err != nil {
+		return 0, err
+	}
+
+	var tasks taskList
+	if err := json.Unmarshal([]byte(body), &tasks); err != nil {
+		return 0, fmt.Errorf("failed to parse task list: %w", err)
+	}
+
+	matching := make(map[string]bool)
+	for _, node := range tasks.Nodes {
+		for taskID, task := range node.Tasks {
+			if task.Cancellable && task.Headers[opaqueIDHeader] == opaqueID {
+				matching[taskID] = true
+			}
+		}
+	}
+
+	cancelled := 0
+	for _, node := range tasks.Nodes {
+		for taskID, task := range node.Tasks {
+			if !matching[taskID] || matching[task.ParentTaskID] {
+				continue
+			}
+			if err := s.cancelTask(ctx, connReq, taskID); err != nil {
+				return cancelled, err
+			}
+			cancelled++
+		}
+	}
+	return cancelled, nil
+}
+
+// cancelTask cancels a single task through _tasks/<task_id>/_cancel
+func (s *ElasticsearchService) cancelTask(ctx context.Context, connReq *models.TestConnectionRequest, taskID string) error {
+	endpoint := "/_tasks/" + url.PathEscape(taskID) + "/_cancel"
+	req, err := http.NewRequestWithContext(ctx, "POST", s.buildURL(connReq, endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := s.addAuthentication(req, connReq); err != nil {
+		return fmt.Errorf("failed to add authentication: %w", err)
+	}
+
+	resp, err := s.doRequest(req, connReq)
+	if err != nil {
+		return fmt.Errorf("failed to cancel task %s: %w", taskID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("failed to cancel task %s: HTTP %d: %s", taskID, resp.StatusCode, string(body))
+	}
+	return nil
+}
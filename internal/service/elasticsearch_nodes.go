@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+const (
+	// nodeDeadBackoff is how long a node is skipped after its first failure; it doubles on each further failure
+	nodeDeadBackoff = 30 * time.Second
+	// nodeMaxDeadBackoff caps the time a failing node is skipped
+	nodeMaxDeadBackoff = 10 * time.Minute
+	// sniffInterval limits how often /_nodes/http is queried for a connection
+	sniffInterval = 5 * time.Minute
+)
+
+// errNotClusterNode is returned for requests to a host that is not one of the connection's
+// nodes, which must not receive the connection's credentials
+var errNotClusterNode = errors.New("the URL does not point at a node of this connection")
+
+// node is a single HTTP endpoint of a cluster
+type node struct {
+	url       *url.URL
+	failures  int
+	deadUntil time.Time
+}
+
+// nodePool tracks the endpoints of one connection and their health
+type nodePool struct {
+	mu        sync.Mutex
+	nodes     []*node
+	next      int
+	lastSniff time.Time
+	sniffing  bool
+}
+
+// nodeKey normalizes scheme, host and port so URLs can be matched against pool nodes
+func nodeKey(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return strings.ToLower(u.Scheme + "://" + net.JoinHostPort(u.Hostname(), port))
+}
+
+// add appends nodes that are not already part of the pool and returns how many were new
+func (p *nodePool) add(urls []*url.URL) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	known := make(map[string]bool, len(p.nodes))
+	for _, n := range p.nodes {
+		known[nodeKey(n.url)] = true
+	}
+
+	added := 0
+	for _, u := range urls {
+		if known[nodeKey(u)] {
+			continue
+		}
+		known[nodeKey(u)] = true
+		p.nodes = append(p.nodes, &node{url: u})
+		added++
+	}
+	return added
+}
+
+// owns reports whether the URL points at one of the pool's nodes
+func (p *nodePool) owns(u *url.URL) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := nodeKey(u)
+	for _, n := range p.nodes {
+		if nodeKey(n.url) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// size returns the number of known nodes
+func (p *nodePool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.nodes)
+}
+
+// pick returns the next live node in round-robin order. When every node is
+// dead, the one whose backoff expires first is tried again.
+func (p *nodePool) pick() *node {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for i := 0; i < len(p.nodes); i++ {
+		n := p.nodes[(p.next+i)%len(p.nodes)]
+		if !n.deadUntil.After(now) {
+			p.next = (p.next + i + 1) % len(p.nodes)
+			return n
+		}
+	}
+
+	var soonest *node
+	for _, n := range p.nodes {
+		if soonest == nil || n.deadUntil.Before(soonest.deadUntil) {
+			soonest = n
+		}
+	}
+	return soonest
+}
+
+// markDead takes a node out of rotation with exponential backoff
+func (p *nodePool) markDead(n *node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n.failures++
+	backoff := nodeDeadBackoff << (n.failures - 1)
+	if backoff > nodeMaxDeadBackoff || backoff <= 0 {
+		backoff = nodeMaxDeadBackoff
+	}
+	n.deadUntil = time.Now().Add(backoff)
+	logging.Warnf("⚠️ Marked node %s as dead for %v", n.url.Host, backoff)
+}
+
+// markAlive returns a node to rotation
+func (p *nodePool) markAlive(n *node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if n.failures > 0 {
+		logging.Infof("✅ Node %s is reachable again", n.url.Host)
+	}
+	n.failures = 0
+	n.deadUntil = time.Time{}
+}
+
+// startSniff reports whether a sniff should run now and, if so, marks one as in progress
+func (p *nodePool) startSniff(force bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sniffing || (!force && time.Since(p.lastSniff) < sniffInterval) {
+		return false
+	}
+	p.sniffing = true
+	return true
+}
+
+// finishSniff records the end of a sniff
+func (p *nodePool) finishSniff() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sniffing = false
+	p.lastSniff = time.Now()
+}
+
+// seedURLs returns the configured endpoints of a connection: the primary host first,
+// followed by any additional nodes
+func seedURLs(connReq *models.TestConnectionRequest) []*url.URL {
+	scheme := "http"
+	if connReq.SSLOrHTTPS {
+		scheme = "https"
+	}
+
+	urls := []*url.URL{{Scheme: scheme, Host: net.JoinHostPort(connReq.Host, connReq.Port)}}
+	for _, address := range connReq.Nodes {
+		if u, err := parseNodeAddress(address, scheme, connReq.Port); err == nil {
+			urls = append(urls, u)
+		} else {
+			logging.Warnf("⚠️ Ignoring invalid node address %q: %v", address, err)
+		}
+	}
+	return urls
+}
+
+// isConfiguredURL reports whether an absolute URL points at one of the connection's
+// configured endpoints, comparing scheme, host and port
+func isConfiguredURL(connReq *models.TestConnectionRequest, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	key := nodeKey(u)
+	for _, seed := range seedURLs(connReq) {
+		if nodeKey(seed) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNodeAddress accepts "host", "host:port" or "scheme://host:port"
+func parseNodeAddress(address, defaultScheme, defaultPort string) (*url.URL, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, fmt.Errorf("empty address")
+	}
+	if !strings.Contains(address, "://") {
+		address = defaultScheme + "://" + address
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+	return &url.URL{Scheme: u.Scheme, Host: u.Host}, nil
+}
+
+// poolFor returns the node pool for a connection, creating it on first use
+func (s *ElasticsearchService) poolFor(connReq *models.TestConnectionRequest) *nodePool {
+	seeds := seedURLs(connReq)
+	keys := make([]string, len(seeds))
+	for i, u := range seeds {
+		keys[i] = nodeKey(u)
+	}
+	key := strings.Join(keys, ",")
+
+	s.poolsMu.Lock()
+	pool, ok := s.pools[key]
+	if !ok {
+		pool = &nodePool{}
+		pool.add(seeds)
+		s.pools[key] = pool
+	}
+	s.poolsMu.Unlock()
+
+	// Discovered nodes join the pool when the sniff completes; requests do not wait for it
+	if connReq.SniffNodes && pool.startSniff(false) {
+		go s.sniffNodes(connReq, pool)
+	}
+	return pool
+}
+
+// sendToCluster sends a request addressed to one of the connection's nodes to a live
+// node instead, marking nodes dead on connection errors and failing over to the next.
+// Requests to any other host are refused, as they carry the connection's credentials.
+func (s *ElasticsearchService) sendToCluster(client *http.Client, req *http.Request, connReq *models.TestConnectionRequest) (*http.Response, error) {
+	pool := s.poolFor(connReq)
+	if !pool.owns(req.URL) {
+		return nil, fmt.Errorf("%w: %s", errNotClusterNode, req.URL.Host)
+	}
+
+	var lastErr error
+	// The pool may grow while failing over, when a sniff finishes
+	for attempt := 0; attempt < pool.size(); attempt++ {
+		n := pool.pick()
+
+		attemptReq, err := cloneRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		// Keep an explicit Host, such as one covered by a request signature, when the node is unchanged
+		if nodeKey(n.url) != nodeKey(req.URL) {
+			attemptReq.URL.Scheme = n.url.Scheme
+			attemptReq.URL.Host = n.url.Host
+			attemptReq.Host = ""
+
+			// A SigV4 signature covers the host, so the request is signed again for the new node
+			if connReq.AuthenticationMethod == "aws_sigv4" {
+				if err := addSigV4Authentication(attemptReq, connReq); err != nil {
+					return nil, fmt.Errorf("failed to sign request: %w", err)
+				}
+			}
+		}
+
+		resp, err := client.Do(attemptReq)
+		if err == nil {
+			pool.markAlive(n)
+			return resp, nil
+		}
+
+		if !isNodeFailure(err) {
+			return nil, err
+		}
+
+		pool.markDead(n)
+		lastErr = err
+
+		if connReq.SniffNodes && pool.startSniff(true) {
+			go s.sniffNodes(connReq, pool)
+		}
+
+		// A request that may have reached the node is only replayed when it is safe to do so
+		if !isDialError(err) && !isIdempotent(req.Method) {
+			break
+		}
+		if req.Body != nil && req.GetBody == nil {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+// sniffNodes discovers the cluster's HTTP endpoints through /_nodes/http and adds them to the pool
+func (s *ElasticsearchService) sniffNodes(connReq *models.TestConnectionRequest, pool *nodePool) {
+	defer pool.finishSniff()
+
+	client, err := s.clientFor(connReq)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), responseTimeout(connReq))
+	defer cancel()
+
+	n := pool.pick()
+	req, err := http.NewRequestWithContext(ctx, "GET", n.url.String()+normalizePathPrefix(derefString(connReq.PathPrefix))+"/_nodes/http", nil)
+	if err != nil {
+		return
+	}
+	if err := s.addAuthentication(req, connReq); err != nil {
+		logging.Warnf("⚠️ Node sniffing skipped: %v", err)
+		return
+	}
+	req.Header.Set("User-Agent", "ElasticGaze/1.0")
+	applyDefaultHeaders(req, connReq)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logging.Warnf("⚠️ Node sniffing failed: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logging.Warnf("⚠️ Node sniffing returned HTTP %d", resp.StatusCode)
+		return
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	var nodesHTTP struct {
+		Nodes map[string]struct {
+			HTTP struct {
+				PublishAddress string `json:"publish_address"`
+			} `json:"http"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(body, &nodesHTTP); err != nil {
+		logging.Warnf("⚠️ Failed to parse sniffed nodes: %v", err)
+		return
+	}
+
+	var discovered []*url.URL
+	for _, info := range nodesHTTP.Nodes {
+		if u, err := parsePublishAddress(info.HTTP.PublishAddress, n.url.Scheme); err == nil {
+			discovered = append(discovered, u)
+		}
+	}
+
+	if added := pool.add(discovered); added > 0 {
+		logging.Infof("🔎 Sniffing discovered %d new node(s)", added)
+	}
+}
+
+// parsePublishAddress parses an http.publish_address, which is either "ip:port"
+// or "hostname/ip:port" when the node has a published hostname
+func parsePublishAddress(address, scheme string) (*url.URL, error) {
+	if address == "" {
+		return nil, fmt.Errorf("empty publish address")
+	}
+
+	hostPort := address
+	if slash := strings.Index(address, "/"); slash >= 0 {
+		hostname := address[:slash]
+		_, port, err := net.SplitHostPort(address[slash+1:])
+		if err != nil {
+			return nil, err
+		}
+		hostPort = net.JoinHostPort(hostname, port)
+	}
+
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		return nil, err
+	}
+	return &url.URL{Scheme: scheme, Host: hostPort}, nil
+}
+
+// cloneRequest copies a request, including a fresh copy of its body when it can be replayed
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// isNodeFailure reports whether an error means the node itself could not be used
+func isNodeFailure(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op != "remote error"
+}
+
+// isDialError reports whether the request failed before it could reach the node
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isIdempotent reports whether a request with this method can be safely sent twice
+func isIdempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"elasticgaze/internal/models"
+)
+
+// proxyConfigError marks an unusable proxy setting, such as an unsupported scheme
+type proxyConfigError struct {
+	err error
+}
+
+func (e *proxyConfigError) Error() string {
+	return fmt.Sprintf("invalid proxy configuration: %v", e.err)
+}
+
+func (e *proxyConfigError) Unwrap() error {
+	return e.err
+}
+
+// buildProxyFunc returns the http.Transport proxy function for a connection, or nil when
+// the connection does not use a proxy. HTTP, HTTPS and SOCKS5 proxies are supported; the
+// proxy credentials are passed as URL user info, which http.Transport turns into
+// Proxy-Authorization for HTTP proxies and username/password auth for SOCKS5.
+func buildProxyFunc(connReq *models.TestConnectionRequest) (func(*http.Request) (*url.URL, error), error) {
+	rawURL := strings.TrimSpace(derefString(connReq.ProxyURL))
+	if rawURL == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := models.ParseProxyURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if username := derefString(connReq.ProxyUsername); username != "" {
+		if password := connReq.ProxyPassword; password != nil {
+			proxyURL.User = url.UserPassword(username, *password)
+		} else {
+			proxyURL.User = url.User(username)
+		}
+	}
+
+	noProxy := parseNoProxy(connReq.NoProxy)
+	return func(req *http.Request) (*url.URL, error) {
+		if noProxy.matches(req.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// noProxyList holds the hosts that bypass a connection's proxy
+type noProxyList struct {
+	all      bool
+	networks []*net.IPNet
+	hosts    []noProxyHost
+}
+
+// noProxyHost is a host or domain suffix entry, optionally limited to one port
+type noProxyHost struct {
+	name   string
+	suffix bool
+	port   string
+}
+
+// parseNoProxy parses no-proxy entries in the same forms as the NO_PROXY environment
+// variable: "*", "host", "host:port", ".domain" or "*.domain", and IP or CIDR ranges
+func parseNoProxy(entries []string) *noProxyList {
+	list := &noProxyList{}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			list.all = true
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list.networks = append(list.networks, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			list.networks = append(list.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		host := noProxyHost{name: entry}
+		if name, port, err := net.SplitHostPort(entry); err == nil {
+			host.name, host.port = name, port
+		}
+		if strings.HasPrefix(host.name, "*.") {
+			host.name = host.name[1:]
+		}
+		if strings.HasPrefix(host.name, ".") {
+			host.suffix = true
+		}
+		list.hosts = append(list.hosts, host)
+	}
+	return list
+}
+
+// matches reports whether requests to the URL should bypass the proxy
+func (l *noProxyList) matches(u *url.URL) bool {
+	if l.all {
+		return true
+	}
+
+	hostname := strings.ToLower(u.Hostname())
+	port := u.Port()
+
+	if ip := net.ParseIP(hostname); ip != nil {
+		for _, network := range l.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	for _, host := range l.hosts {
+		if host.port != "" && host.port != port {
+			continue
+		}
+		if host.suffix {
+			// ".example.com" matches example.com and any of its subdomains
+			if hostname == host.name[1:] || strings.HasSuffix(hostname, host.name) {
+				return true
+			}
+		} else if hostname == host.name || strings.HasSuffix(hostname, "."+host.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyProxyError maps failures to reach or authenticate with the proxy to dedicated error codes
+func classifyProxyError(err error) (code string, message string, ok bool) {
+	var configErr *proxyConfigError
+	if errors.As(err, &configErr) {
+		return "PROXY_CONFIG_ERROR", "Invalid proxy configuration", true
+	}
+
+	// http.Transport reports a CONNECT refused with 407 by its bare status text
+	errText := err.Error()
+	if strings.Contains(errText, "Proxy Authentication Required") {
+		return "PROXY_AUTH_FAILED", "Proxy authentication failed", true
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || (opErr.Op != "proxyconnect" && !strings.HasPrefix(opErr.Op, "socks")) {
+		return "", "", false
+	}
+
+	if strings.Contains(errText, "authentication failed") ||
+		strings.Contains(errText, "unsupported authentication method") {
+		return "PROXY_AUTH_FAILED", "Proxy authentication failed", true
+	}
+	return "PROXY_ERROR", "Connection through proxy failed", true
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"elasticgaze/internal/models"
+)
+
+func TestClientForRoutesThroughProxy(t *testing.T) {
+	var proxied []string
+	var proxyAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target
+		proxied = append(proxied, r.URL.String())
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"status":"green"}`)
+	}))
+	defer proxy.Close()
+
+	bypassed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer bypassed.Close()
+	bypassedURL, _ := url.Parse(bypassed.URL)
+
+	connReq := &models.TestConnectionRequest{
+		Host:          "es.internal",
+		Port:          "9200",
+		ProxyURL:      models.StringPtr(proxy.URL),
+		ProxyUsername: models.StringPtr("proxyuser"),
+		ProxyPassword: models.StringPtr("proxypass"),
+		NoProxy:       []string{bypassedURL.Hostname()},
+	}
+
+	client, err := NewElasticsearchService().clientFor(connReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get("http://es.internal:9200/_cluster/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != `{"status":"green"}` {
+		t.Errorf("body = %q, want the proxy's answer", body)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://es.internal:9200/_cluster/health" {
+		t.Errorf("proxied requests = %v, want the cluster URL", proxied)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("proxyuser:proxypass"))
+	if proxyAuth != wantAuth {
+		t.Errorf("Proxy-Authorization = %q, want %q", proxyAuth, wantAuth)
+	}
+
+	// Hosts on the no-proxy list are reached directly
+	resp, err = client.Get(bypassed.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "direct" || len(proxied) != 1 {
+		t.Errorf("no-proxy host: body = %q, proxied = %v", body, proxied)
+	}
+}
+
+func TestClientForProxyAuthenticationFailure(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	connReq := &models.TestConnectionRequest{
+		Host:       "es.internal",
+		Port:       "9243",
+		SSLOrHTTPS: true,
+		ProxyURL:   models.StringPtr(proxy.URL),
+	}
+
+	client, err := NewElasticsearchService().clientFor(connReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get("https://es.internal:9243/")
+	if err == nil {
+		t.Fatal("request through a proxy that refuses CONNECT succeeded")
+	}
+	if code, _, ok := classifyProxyError(err); !ok || code != "PROXY_AUTH_FAILED" {
+		t.Errorf("classifyProxyError(%v) = %q, %v, want PROXY_AUTH_FAILED", err, code, ok)
+	}
+}
+
+func TestClientForRejectsUnsupportedProxy(t *testing.T) {
+	connReq := &models.TestConnectionRequest{Host: "es.internal", Port: "9200", ProxyURL: models.StringPtr("ftp://proxy:21")}
+
+	_, err := NewElasticsearchService().clientFor(connReq)
+	var configErr *proxyConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("clientFor: err = %v, want a proxy configuration error", err)
+	}
+	if code, _, ok := classifyProxyError(err); !ok || code != "PROXY_CONFIG_ERROR" {
+		t.Errorf("classifyProxyError = %q, %v, want PROXY_CONFIG_ERROR", code, ok)
+	}
+}
+
+func TestNoProxyMatches(t *testing.T) {
+	list := parseNoProxy([]string{".corp.example", "*.internal", "db.local:9200", "10.0.0.0/8", "192.168.1.5", " "})
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"http://corp.example:9200", true},
+		{"http://es.corp.example:9200", true},
+		{"http://notcorp.example:9200", false},
+		{"http://es.internal:9200", true},
+		{"http://db.local:9200", true},
+		{"http://db.local:9300", false},
+		{"http://sub.db.local:9200", true},
+		{"http://10.1.2.3:9200", true},
+		{"http://11.1.2.3:9200", false},
+		{"http://192.168.1.5:9200", true},
+		{"http://192.168.1.6:9200", false},
+		{"http://ES.Internal:9200", true},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := list.matches(u); got != tt.want {
+			t.Errorf("matches(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	u, _ := url.Parse("http://anything:9200")
+	if !parseNoProxy([]string{"*"}).matches(u) {
+		t.Error(`"*" does not bypass the proxy for every host`)
+	}
+}
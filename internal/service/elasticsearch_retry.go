@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond // Backoff before the first retry; doubles with every attempt
+	retryMaxDelay  = 30 * time.Second       // Upper bound for backoff and Retry-After
+)
+
+// connectTimeout returns the limit for establishing a TCP connection to the cluster
+func connectTimeout(connReq *models.TestConnectionRequest) time.Duration {
+	return secondsOr(connReq.ConnectTimeoutSeconds, models.DefaultConnectTimeoutSeconds)
+}
+
+// tlsTimeout returns the limit for the TLS handshake
+func tlsTimeout(connReq *models.TestConnectionRequest) time.Duration {
+	return secondsOr(connReq.TLSTimeoutSeconds, models.DefaultTLSTimeoutSeconds)
+}
+
+// responseTimeout returns the limit for a whole request, including reading the response
+func responseTimeout(connReq *models.TestConnectionRequest) time.Duration {
+	return secondsOr(connReq.ResponseTimeoutSeconds, models.DefaultResponseTimeoutSeconds)
+}
+
+// maxRetries returns how often an idempotent request is retried on a retryable status
+func maxRetries(connReq *models.TestConnectionRequest) int {
+	if connReq.MaxRetries == nil || *connReq.MaxRetries < 0 {
+		return models.DefaultMaxRetries
+	}
+	return *connReq.MaxRetries
+}
+
+func secondsOr(value *int, fallback int) time.Duration {
+	if value == nil || *value <= 0 {
+		return time.Duration(fallback) * time.Second
+	}
+	return time.Duration(*value) * time.Second
+}
+
+// sendWithRetry sends the request to the cluster, retrying idempotent requests with
+// exponential backoff while Elasticsearch answers 429, 502, 503 or 504
+func (s *ElasticsearchService) sendWithRetry(client *http.Client, req *http.Request, connReq *models.TestConnectionRequest) (*http.Response, error) {
+	retries := maxRetries(connReq)
+	if !canRetry(req) {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := s.sendToCluster(client, req, connReq)
+		if err != nil || attempt >= retries || !isRetryableStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		delay := retryDelay(attempt, resp)
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, nil // The retry could not finish in time; report the last answer
+		}
+
+		// Drain a little of the body so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		logging.Warnf("⏳ %s %s returned %d, retrying in %v (attempt %d of %d)",
+			req.Method, req.URL.Path, resp.StatusCode, delay.Round(time.Millisecond), attempt+1, retries)
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req, err = cloneRequest(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// canRetry reports whether sending the request again cannot change the outcome:
+// idempotent methods and read-only requests such as POST _search, with a replayable body
+func canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if isIdempotent(req.Method) {
+		return true
+	}
+	class, _ := classifyRequest(req.Method, req.URL.EscapedPath(), nil)
+	return class == requestClassRead
+}
+
+// isRetryableStatus reports whether a status means the cluster is busy or a proxy in
+// front of it could not reach it
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay honours Retry-After, in seconds or as an HTTP date, and otherwise backs
+// off exponentially with jitter
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, retryMaxDelay)
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return min(max(time.Until(at), 0), retryMaxDelay)
+		}
+	}
+
+	delay := min(retryBaseDelay<<attempt, retryMaxDelay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelOnClose releases a request's timeout context once the caller has read the body
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
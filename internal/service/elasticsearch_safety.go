@@ -0,0 +1,288 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/models"
+)
+
+// confirmationTTL is how long a confirmation token for a destructive request stays valid
+const confirmationTTL = 2 * time.Minute
+
+// Request classes used by the safety mode
+const (
+	requestClassRead        = "read"
+	requestClassWrite       = "write"
+	requestClassDestructive = "destructive"
+)
+
+// readOnlyPostEndpoints are endpoints that only read data even though they are called with POST
+var readOnlyPostEndpoints = map[string]bool{
+	"_search": true, "_msearch": true, "_count": true, "_mget": true, "_field_caps": true,
+	"_validate": true, "_explain": true, "_termvectors": true, "_mtermvectors": true,
+	"_analyze": true, "_rank_eval": true, "_render": true, "_pit": true, "_async_search": true,
+	"_sql": true, "_eql": true, "_knn_search": true, "_terms_enum": true, "_query": true,
+	"_ppl": true,
+}
+
+// cleanupDeleteEndpoints are DELETE endpoints that only release search resources
+var cleanupDeleteEndpoints = map[string]bool{
+	"_pit": true, "_async_search": true, "_search": true, "_sql": true, "_eql": true,
+}
+
+// destructiveEndpoints are endpoints that remove or rewrite data, or change the cluster
+// itself, whatever method they are called with
+var destructiveEndpoints = map[string]bool{
+	"_delete_by_query": true, "_update_by_query": true, "_shutdown": true, "_close": true,
+	"_restore": true, "_forcemerge": true,
+}
+
+// destructiveClusterEndpoints are second-level endpoints under _cluster or _nodes that change cluster state
+var destructiveClusterEndpoints = map[string]bool{
+	"settings": true, "reroute": true, "voting_config_exclusions": true, "shutdown": true,
+}
+
+// classifyRequest sorts a REST request into read, write or destructive by method,
+// escaped path and body, returning a short reason for anything that is not a plain read.
+// The body only matters for _bulk and _aliases, which delete data through their actions.
+func classifyRequest(method, path string, body *string) (class string, reason string) {
+	method = strings.ToUpper(method)
+	segments := pathSegments(path)
+
+	for i, segment := range segments {
+		if destructiveEndpoints[segment] {
+			return requestClassDestructive, fmt.Sprintf("%s calls %s", method, segment)
+		}
+		if method == "POST" || method == "PUT" {
+			if segment == "_bulk" && body != nil && bulkDeletes(*body) {
+				return requestClassDestructive, "_bulk deletes documents"
+			}
+			if segment == "_aliases" && body != nil && aliasesRemoveIndex(*body) {
+				return requestClassDestructive, "_aliases removes an index"
+			}
+		}
+		if (segment == "_cluster" || segment == "_nodes") && method != "GET" && method != "HEAD" {
+			for _, next := range segments[i+1:] {
+				if destructiveClusterEndpoints[next] {
+					return requestClassDestructive, fmt.Sprintf("%s changes cluster %s", method, next)
+				}
+			}
+		}
+	}
+
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return requestClassRead, ""
+	case "DELETE":
+		if len(segments) > 0 && cleanupDeleteEndpoints[segments[0]] {
+			return requestClassRead, ""
+		}
+		return requestClassDestructive, fmt.Sprintf("DELETE %s", "/"+strings.Join(segments, "/"))
+	case "POST":
+		for _, segment := range segments {
+			if readOnlyPostEndpoints[segment] {
+				return requestClassRead, ""
+			}
+		}
+	}
+
+	return requestClassWrite, fmt.Sprintf("%s modifies data", method)
+}
+
+// bulkDeletes reports whether a _bulk body has a delete action. Source lines that follow
+// index, create and update actions are skipped, so a document with a "delete" field does
+// not count. A line that is not JSON is treated as a delete, as its action is unknown.
+func bulkDeletes(body string) bool {
+	expectSource := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if expectSource {
+			expectSource = false
+			continue
+		}
+
+		var action map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &action); err != nil {
+			return true
+		}
+		for name := range action {
+			switch name {
+			case "delete":
+				return true
+			case "index", "create", "update":
+				expectSource = true
+			}
+		}
+	}
+	return false
+}
+
+// aliasesRemoveIndex reports whether an _aliases body has a remove_index action, which
+// deletes the index. A body that cannot be parsed is treated as removing one.
+func aliasesRemoveIndex(body string) bool {
+	var request struct {
+		Actions []map[string]json.RawMessage `json:"actions"`
+	}
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		return true
+	}
+	for _, action := range request.Actions {
+		if _, ok := action["remove_index"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pathSegments splits an escaped URL path into its non-empty segments and unescapes each
+// once, as Elasticsearch does: /idx/_doc/a%2F_search has the three segments idx, _doc
+// and a/_search, and /idx/_doc/%255Fsearch has the document ID %5Fsearch
+func pathSegments(escapedPath string) []string {
+	var segments []string
+	for _, segment := range strings.Split(escapedPath, "/") {
+		if segment == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		segments = append(segments, strings.ToLower(segment))
+	}
+	return segments
+}
+
+// trimPathPrefix removes the connection's path prefix from the start of path when it is
+// followed by a slash or ends the path, so /es does not strip /esindex
+func trimPathPrefix(path, prefix string) string {
+	if prefix == "" || !strings.HasPrefix(path, prefix) {
+		return path
+	}
+	rest := path[len(prefix):]
+	if rest != "" && !strings.HasPrefix(rest, "/") {
+		return path
+	}
+	return rest
+}
+
+// pendingConfirmation is a destructive request waiting to be confirmed
+type pendingConfirmation struct {
+	key       string
+	expiresAt time.Time
+}
+
+// confirmationStore hands out one-time tokens that allow a specific destructive request to run
+type confirmationStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{pending: make(map[string]pendingConfirmation)}
+}
+
+// confirmationKey binds a token to one connection, method, URL and body
+func confirmationKey(configID int, method, requestURL string, body *string) string {
+	hash := sha256.New()
+	if body != nil {
+		hash.Write([]byte(*body))
+	}
+	return fmt.Sprintf("%d|%s|%s|%x", configID, strings.ToUpper(method), requestURL, hash.Sum(nil))
+}
+
+// issue creates a token for the request described by key
+func (c *confirmationStore) issue(key string) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for existing, pending := range c.pending {
+		if now.After(pending.expiresAt) {
+			delete(c.pending, existing)
+		}
+	}
+	c.pending[token] = pendingConfirmation{key: key, expiresAt: now.Add(confirmationTTL)}
+	return token, nil
+}
+
+// consume reports whether token was issued for key and has not expired. A token
+// can only be consumed once, whether or not it matched.
+func (c *confirmationStore) consume(token, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[token]
+	if !ok {
+		return false
+	}
+	delete(c.pending, token)
+	return pending.key == key && time.Now().Before(pending.expiresAt)
+}
+
+// checkSafetyMode applies the connection's safety mode to a REST request. It returns nil
+// when the request may run, or the response to send back instead.
+func (s *ElasticsearchService) checkSafetyMode(config *models.Config, req *models.ElasticsearchRestRequest, requestURL string) (*models.ElasticsearchRestResponse, error) {
+	mode := config.SafetyMode
+	if mode == "" || mode == models.SafetyModeUnrestricted {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request URL: %w", err)
+	}
+	path := trimPathPrefix(parsed.EscapedPath(), normalizePathPrefix(derefString(config.PathPrefix)))
+
+	class, reason := classifyRequest(req.Method, path, req.Body)
+	if class == requestClassRead {
+		return nil, nil
+	}
+
+	if mode == models.SafetyModeReadOnly {
+		return &models.ElasticsearchRestResponse{
+			Success:      false,
+			StatusCode:   403,
+			ErrorDetails: fmt.Sprintf("Connection %q is read-only: %s", config.ConnectionName, reason),
+			ErrorCode:    "BLOCKED_BY_SAFETY_MODE",
+		}, nil
+	}
+
+	if class != requestClassDestructive {
+		return nil, nil
+	}
+
+	key := confirmationKey(config.ID, req.Method, requestURL, req.Body)
+	if req.ConfirmationToken != nil && *req.ConfirmationToken != "" {
+		if s.confirmations.consume(*req.ConfirmationToken, key) {
+			return nil, nil
+		}
+	}
+
+	token, err := s.confirmations.issue(key)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ElasticsearchRestResponse{
+		Success:           false,
+		StatusCode:        428,
+		ErrorDetails:      fmt.Sprintf("Destructive request on %q needs confirmation: %s", config.ConnectionName, reason),
+		ErrorCode:         "CONFIRMATION_REQUIRED",
+		ConfirmationToken: token,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import "testing"
+
+func TestClassifyRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/_cluster/health", requestClassRead},
+		{"HEAD", "/logs", requestClassRead},
+		{"get", "/logs/_search", requestClassRead},
+		{"POST", "/logs/_search", requestClassRead},
+		{"POST", "/logs/_count", requestClassRead},
+		{"POST", "/_sql", requestClassRead},
+		{"POST", "/logs/_doc", requestClassWrite},
+		{"PUT", "/logs", requestClassWrite},
+		{"POST", "/_bulk", requestClassWrite},
+		{"POST", "/_cat/indices", requestClassWrite},
+		{"DELETE", "/logs", requestClassDestructive},
+		{"DELETE", "/logs/_doc/1", requestClassDestructive},
+		{"DELETE", "/_pit", requestClassRead},
+		{"DELETE", "/_search/scroll", requestClassRead},
+		{"POST", "/logs/_delete_by_query", requestClassDestructive},
+		{"POST", "/logs/_update_by_query", requestClassDestructive},
+		{"GET", "/logs/_forcemerge", requestClassDestructive},
+		{"POST", "/logs/_close", requestClassDestructive},
+		{"PUT", "/_cluster/settings", requestClassDestructive},
+		{"GET", "/_cluster/settings", requestClassRead},
+		{"POST", "/_cluster/reroute", requestClassDestructive},
+		{"POST", "/_nodes/reload_secure_settings", requestClassWrite},
+		// Encoded slashes stay inside the document ID
+		{"PUT", "/logs/_doc/a%2F_search", requestClassWrite},
+		{"POST", "/logs/_doc/a%2F_search", requestClassWrite},
+		// Each segment is decoded once, like Elasticsearch does
+		{"POST", "/logs/%5Fsearch", requestClassRead},
+		{"POST", "/logs/_doc/%255Fsearch", requestClassWrite},
+		{"POST", "/logs/%5Fdelete_by_query", requestClassDestructive},
+		{"POST", "/logs/_DELETE_BY_QUERY", requestClassDestructive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			got, reason := classifyRequest(tt.method, tt.path, nil)
+			if got != tt.want {
+				t.Errorf("classifyRequest(%q, %q) = %q (%s), want %q", tt.method, tt.path, got, reason, tt.want)
+			}
+			if (got == requestClassRead) != (reason == "") {
+				t.Errorf("classifyRequest(%q, %q) reason = %q for class %q", tt.method, tt.path, reason, got)
+			}
+		})
+	}
+}
+
+func TestClassifyRequestBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string
+	}{
+		{"bulk index", "POST", "/_bulk", `{"index":{"_index":"logs"}}` + "\n" + `{"a":1}` + "\n", requestClassWrite},
+		{"bulk delete", "POST", "/_bulk", `{"index":{"_index":"logs"}}` + "\n" + `{"a":1}` + "\n" + `{"delete":{"_index":"logs","_id":"1"}}` + "\n", requestClassDestructive},
+		{"bulk delete on an index", "PUT", "/logs/_bulk", `{ "delete" : { "_id" : "1" } }` + "\n", requestClassDestructive},
+		{"bulk source with a delete field", "POST", "/_bulk", `{"create":{"_index":"logs"}}` + "\n" + `{"delete":true}` + "\n", requestClassWrite},
+		{"bulk update", "POST", "/_bulk", `{"update":{"_index":"logs","_id":"1"}}` + "\n" + `{"doc":{"a":2}}` + "\n", requestClassWrite},
+		{"bulk unparseable", "POST", "/_bulk", "not json\n", requestClassDestructive},
+		{"aliases add", "POST", "/_aliases", `{"actions":[{"add":{"index":"logs-1","alias":"logs"}}]}`, requestClassWrite},
+		{"aliases remove", "POST", "/_aliases", `{"actions":[{"remove":{"index":"logs-1","alias":"logs"}}]}`, requestClassWrite},
+		{"aliases remove_index", "POST", "/_aliases", `{"actions":[{"add":{"index":"logs-2","alias":"logs"}},{"remove_index":{"index":"logs-1"}}]}`, requestClassDestructive},
+		{"aliases unparseable", "POST", "/_aliases", `{"actions":`, requestClassDestructive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, reason := classifyRequest(tt.method, tt.path, &tt.body); got != tt.want {
+				t.Errorf("classifyRequest(%q, %q) = %q (%s), want %q", tt.method, tt.path, got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimPathPrefix(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   string
+	}{
+		{"/es/logs/_search", "/es", "/logs/_search"},
+		{"/es", "/es", ""},
+		{"/esindex/_delete_by_query", "/es", "/esindex/_delete_by_query"},
+		{"/logs/_search", "", "/logs/_search"},
+		{"/other/es/logs", "/es", "/other/es/logs"},
+	}
+
+	for _, tt := range tests {
+		if got := trimPathPrefix(tt.path, tt.prefix); got != tt.want {
+			t.Errorf("trimPathPrefix(%q, %q) = %q, want %q", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
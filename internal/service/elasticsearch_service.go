@@ -2,45 +2,69 @@ package service
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"elasticgaze/internal/logging"
 	"elasticgaze/internal/models"
 )
 
+// fanOutConcurrency limits how many connections a fanned-out request runs on at once
+const fanOutConcurrency = 8
+
 // ElasticsearchService handles Elasticsearch connection testing
 type ElasticsearchService struct {
-	client *http.Client
+	clientsMu sync.Mutex
+	clients   map[string]*http.Client // HTTP clients keyed by transport settings, see clientFor
+	poolsMu   sync.Mutex
+	pools     map[string]*nodePool // Node pools keyed by configured endpoints, see poolFor
+	tokens    *tokenStore
+	tunnels   *tunnelManager
+
+	confirmations *confirmationStore // One-time tokens for destructive requests, see checkSafetyMode
+	credentials   *credentialCache   // Resolved credential references, see credential
+	recorder      RequestRecorder    // Receives every executed REST request, see SetRequestRecorder
+	executions    *executionRegistry // Cancellable calls in flight, see StartExecution
 }
 
 // NewElasticsearchService creates a new Elasticsearch service
 func NewElasticsearchService() *ElasticsearchService {
-	// Create HTTP client with timeout and SSL configuration
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // For development - in production, you might want to make this configurable
-			},
-		},
-	}
-
 	return &ElasticsearchService{
-		client: client,
+		clients: make(map[string]*http.Client),
+		pools:   make(map[string]*nodePool),
+		tokens:  newTokenStore(),
+		tunnels: newTunnelManager(),
+
+		confirmations: newConfirmationStore(),
+		credentials:   newCredentialCache(),
+		executions:    newExecutionRegistry(),
 	}
 }
 
 // TestConnection tests the connection to an Elasticsearch cluster
-func (s *ElasticsearchService) TestConnection(req *models.TestConnectionRequest) (*models.TestConnectionResponse, error) {
+func (s *ElasticsearchService) TestConnection(ctx context.Context, req *models.TestConnectionRequest) (*models.TestConnectionResponse, error) {
 	logging.Infof("🔍 Testing Elasticsearch connection to %s:%s (SSL: %v, Auth: %s)",
 		req.Host, req.Port, req.SSLOrHTTPS, req.AuthenticationMethod)
 
+	// Resolve the endpoint from the Cloud ID before validating the host
+	if err := applyCloudID(req); err != nil {
+		logging.Errorf("❌ Invalid Cloud ID: %v", err)
+		return &models.TestConnectionResponse{
+			Success:      false,
+			Message:      "Invalid Cloud ID",
+			ErrorDetails: err.Error(),
+			ErrorCode:    "INVALID_CLOUD_ID",
+		}, nil
+	}
+
 	// Validate the request
 	if err := req.Validate(); err != nil {
 		logging.Errorf("❌ Connection test validation failed: %v", err)
@@ -57,7 +81,7 @@ func (s *ElasticsearchService) TestConnection(req *models.TestConnectionRequest)
 	logging.Infof("🌐 Connection URL: %s", url)
 
 	// Create HTTP request
-	httpReq, err := http.NewRequest("GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		logging.Errorf("❌ Failed to create HTTP request: %v", err)
 		return &models.TestConnectionResponse{
@@ -91,7 +115,7 @@ func (s *ElasticsearchService) TestConnection(req *models.TestConnectionRequest)
 	// Make the request
 	logging.Info("🚀 Making HTTP request...")
 	start := time.Now()
-	resp, err := s.client.Do(httpReq)
+	resp, err := s.doRequest(httpReq, req)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -99,13 +123,7 @@ func (s *ElasticsearchService) TestConnection(req *models.TestConnectionRequest)
 		errorDetails := fmt.Sprintf("Connection failed after %v\nURL: %s\nError: %v", duration, url, err)
 
 		// Check for specific error types
-		errorCode := "CONNECTION_ERROR"
-		errorMessage := "Connection failed"
-
-		if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
-			errorCode = "TIMEOUT_ERROR"
-			errorMessage = "Connection timeout"
-		}
+		errorCode, errorMessage := classifyConnectionError(err)
 
 		return &models.TestConnectionResponse{
 			Success:      false,
@@ -152,6 +170,9 @@ func (s *ElasticsearchService) TestConnection(req *models.TestConnectionRequest)
 		case 404:
 			errorMessage = "Elasticsearch not found at this URL"
 			errorCode = "NOT_FOUND"
+		case 407:
+			errorMessage = "Proxy authentication failed"
+			errorCode = "PROXY_AUTH_FAILED"
 		case 500:
 			errorMessage = "Elasticsearch server error"
 			errorCode = "SERVER_ERROR"
@@ -166,20 +187,7 @@ func (s *ElasticsearchService) TestConnection(req *models.TestConnectionRequest)
 	}
 
 	// Parse Elasticsearch info response
-	var esInfo struct {
-		Name        string `json:"name"`
-		ClusterName string `json:"cluster_name"`
-		ClusterUUID string `json:"cluster_uuid"`
-		Version     struct {
-			Number        string `json:"number"`
-			BuildFlavor   string `json:"build_flavor"`
-			BuildType     string `json:"build_type"`
-			BuildHash     string `json:"build_hash"`
-			BuildDate     string `json:"build_date"`
-			BuildSnapshot bool   `json:"build_snapshot"`
-		} `json:"version"`
-		Tagline string `json:"tagline"`
-	}
+	var esInfo models.ClusterInfo
 
 	if err := json.Unmarshal(body, &esInfo); err != nil {
 		logging.Warnf("⚠️ Response parsing failed (connection still successful): %v", err)
@@ -192,71 +200,86 @@ func (s *ElasticsearchService) TestConnection(req *models.TestConnectionRequest)
 		}, nil
 	}
 
+	profile := models.NewClusterProfile(&esInfo)
+
 	// Success!
 	logging.Info("🎉 Connection test successful!")
 	logging.Infof("🏷️  Cluster Name: %s", esInfo.ClusterName)
 	logging.Infof("🏷️  Cluster UUID: %s", esInfo.ClusterUUID)
-	logging.Infof("📦 Elasticsearch Version: %s (%s)", esInfo.Version.Number, esInfo.Version.BuildFlavor)
-	logging.Infof("🏗️  Build: %s (%s)", esInfo.Version.BuildHash[:8], esInfo.Version.BuildDate)
+	logging.Infof("📦 %s Version: %s (%s)", profile.Distribution, esInfo.Version.Number, esInfo.Version.BuildFlavor)
+	logging.Infof("🏗️  Build: %s (%s)", shortHash(esInfo.Version.BuildHash), esInfo.Version.BuildDate)
 
 	return &models.TestConnectionResponse{
 		Success:     true,
 		Message:     "Connection successful",
 		ClusterName: esInfo.ClusterName,
 		Version:     esInfo.Version.Number,
+		Profile:     profile,
 	}, nil
 }
 
-// GetClusterDashboardData fetches all cluster data needed for the dashboard
-func (s *ElasticsearchService) GetClusterDashboardData(config *models.Config) (*models.ProcessedDashboardData, error) {
+// GetClusterDashboardData fetches all cluster data needed for the dashboard. Only the
+// cluster info is required; sections the cluster does not support, or that fail, are
+// left empty and reported in Warnings instead of failing the whole dashboard.
+func (s *ElasticsearchService) GetClusterDashboardData(ctx context.Context, config *models.Config) (*models.ProcessedDashboardData, error) {
 	logging.Infof("🔍 Fetching cluster dashboard data for %s", config.ConnectionName)
 
 	// Create test connection request from config
-	testReq := &models.TestConnectionRequest{
-		Host:                 config.Host,
-		Port:                 config.Port,
-		SSLOrHTTPS:           config.SSLOrHTTPS,
-		AuthenticationMethod: config.AuthenticationMethod,
-		Username:             config.Username,
-		Password:             config.Password,
-	}
+	testReq := config.ToConnectionRequest()
 
-	// Get cluster info
-	clusterInfo, err := s.getClusterInfo(testReq)
+	// Get cluster info, which also refreshes the capability profile
+	clusterInfo, err := s.getClusterInfo(ctx, testReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cluster info: %w", err)
 	}
+	profile := models.NewClusterProfile(clusterInfo)
+	testReq.ClusterProfile = profile
+
+	var warnings []string
 
 	// Get cluster health
-	clusterHealth, err := s.getClusterHealth(testReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cluster health: %w", err)
+	var clusterHealth *models.ClusterHealth
+	if profile.SupportsClusterHealth() {
+		if clusterHealth, err = s.getClusterHealth(ctx, testReq); err != nil {
+			logging.Warnf("⚠️ Failed to get cluster health: %v", err)
+			warnings = append(warnings, fmt.Sprintf("cluster health unavailable: %v", err))
+		}
+	} else {
+		warnings = append(warnings, "cluster health is not available on serverless projects")
 	}
 
 	// Get nodes info
-	nodesInfo, err := s.getNodesInfo(testReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get nodes info: %w", err)
+	var nodesInfo *models.NodesInfo
+	if profile.SupportsNodesInfo() {
+		if nodesInfo, err = s.getNodesInfo(ctx, testReq); err != nil {
+			logging.Warnf("⚠️ Failed to get nodes info: %v", err)
+			warnings = append(warnings, fmt.Sprintf("nodes info unavailable: %v", err))
+		}
+	} else {
+		warnings = append(warnings, "nodes info is not available on serverless projects")
 	}
 
 	// Get indices stats
-	indicesStats, err := s.getIndicesStats(testReq)
+	indicesStats, err := s.getIndicesStats(ctx, testReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get indices stats: %w", err)
+		logging.Warnf("⚠️ Failed to get indices stats: %v", err)
+		warnings = append(warnings, fmt.Sprintf("indices stats unavailable: %v", err))
 	}
 
 	// Process the data
 	processedData := s.processClusterData(clusterInfo, clusterHealth, nodesInfo, indicesStats)
+	processedData.Profile = profile
+	processedData.Warnings = warnings
 
 	logging.Info("✅ Successfully fetched cluster dashboard data")
 	return processedData, nil
 }
 
 // getClusterInfo fetches cluster information
-func (s *ElasticsearchService) getClusterInfo(connReq *models.TestConnectionRequest) (*models.ClusterInfo, error) {
+func (s *ElasticsearchService) getClusterInfo(ctx context.Context, connReq *models.TestConnectionRequest) (*models.ClusterInfo, error) {
 	url := s.buildURL(connReq, "/")
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -265,7 +288,7 @@ func (s *ElasticsearchService) getClusterInfo(connReq *models.TestConnectionRequ
 		return nil, fmt.Errorf("failed to add authentication: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.doRequest(req, connReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -289,10 +312,10 @@ func (s *ElasticsearchService) getClusterInfo(connReq *models.TestConnectionRequ
 }
 
 // getClusterHealth fetches cluster health
-func (s *ElasticsearchService) getClusterHealth(connReq *models.TestConnectionRequest) (*models.ClusterHealth, error) {
+func (s *ElasticsearchService) getClusterHealth(ctx context.Context, connReq *models.TestConnectionRequest) (*models.ClusterHealth, error) {
 	url := s.buildURL(connReq, "/_cluster/health")
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -301,7 +324,7 @@ func (s *ElasticsearchService) getClusterHealth(connReq *models.TestConnectionRe
 		return nil, fmt.Errorf("failed to add authentication: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.doRequest(req, connReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -325,10 +348,10 @@ func (s *ElasticsearchService) getClusterHealth(connReq *models.TestConnectionRe
 }
 
 // getNodesInfo fetches nodes information
-func (s *ElasticsearchService) getNodesInfo(connReq *models.TestConnectionRequest) (*models.NodesInfo, error) {
+func (s *ElasticsearchService) getNodesInfo(ctx context.Context, connReq *models.TestConnectionRequest) (*models.NodesInfo, error) {
 	url := s.buildURL(connReq, "/_nodes")
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -337,7 +360,7 @@ func (s *ElasticsearchService) getNodesInfo(connReq *models.TestConnectionReques
 		return nil, fmt.Errorf("failed to add authentication: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.doRequest(req, connReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -361,10 +384,10 @@ func (s *ElasticsearchService) getNodesInfo(connReq *models.TestConnectionReques
 }
 
 // getIndicesStats fetches indices statistics
-func (s *ElasticsearchService) getIndicesStats(connReq *models.TestConnectionRequest) (*models.IndicesStats, error) {
+func (s *ElasticsearchService) getIndicesStats(ctx context.Context, connReq *models.TestConnectionRequest) (*models.IndicesStats, error) {
 	url := s.buildURL(connReq, "/_stats")
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -373,7 +396,7 @@ func (s *ElasticsearchService) getIndicesStats(connReq *models.TestConnectionReq
 		return nil, fmt.Errorf("failed to add authentication: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
+	resp, err := s.doRequest(req, connReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -396,52 +419,68 @@ func (s *ElasticsearchService) getIndicesStats(connReq *models.TestConnectionReq
 	return &indicesStats, nil
 }
 
-// processClusterData processes raw cluster data into frontend-friendly format
+// processClusterData processes raw cluster data into frontend-friendly format.
+// Sections whose source data is missing are left nil.
 func (s *ElasticsearchService) processClusterData(clusterInfo *models.ClusterInfo, clusterHealth *models.ClusterHealth, nodesInfo *models.NodesInfo, indicesStats *models.IndicesStats) *models.ProcessedDashboardData {
+	processed := &models.ProcessedDashboardData{
+		ClusterInfo:   clusterInfo,
+		ClusterHealth: clusterHealth,
+	}
+
 	// Process node counts
-	nodeCounts := &models.NodeCounts{
-		Total: len(nodesInfo.Nodes),
-	}
-
-	for _, node := range nodesInfo.Nodes {
-		for _, role := range node.Roles {
-			switch role {
-			case "master":
-				nodeCounts.Master++
-			case "data", "data_content", "data_hot", "data_warm", "data_cold", "data_frozen":
-				nodeCounts.Data++
-			case "ingest":
-				nodeCounts.Ingest++
+	if nodesInfo != nil {
+		nodeCounts := &models.NodeCounts{
+			Total: len(nodesInfo.Nodes),
+		}
+
+		for _, node := range nodesInfo.Nodes {
+			for _, role := range node.Roles {
+				switch role {
+				case "master", "cluster_manager":
+					nodeCounts.Master++
+				case "data", "data_content", "data_hot", "data_warm", "data_cold", "data_frozen":
+					nodeCounts.Data++
+				case "ingest":
+					nodeCounts.Ingest++
+				}
 			}
 		}
+		processed.NodeCounts = nodeCounts
 	}
 
 	// Process shard counts
-	shardCounts := &models.ShardCounts{
-		Primary: clusterHealth.ActivePrimaryShards,
-		Total:   clusterHealth.ActiveShards,
+	if clusterHealth != nil {
+		shardCounts := &models.ShardCounts{
+			Primary: clusterHealth.ActivePrimaryShards,
+			Total:   clusterHealth.ActiveShards,
+		}
+		shardCounts.Replica = shardCounts.Total - shardCounts.Primary
+		processed.ShardCounts = shardCounts
 	}
-	shardCounts.Replica = shardCounts.Total - shardCounts.Primary
 
 	// Process index metrics
-	indexMetrics := &models.IndexMetrics{
-		DocumentCount:  indicesStats.All.Total.Docs.Count,
-		DiskUsageBytes: indicesStats.All.Total.Store.SizeInBytes,
-		DiskUsage:      formatBytes(indicesStats.All.Total.Store.SizeInBytes),
+	if indicesStats != nil {
+		processed.IndexMetrics = &models.IndexMetrics{
+			DocumentCount:  indicesStats.All.Total.Docs.Count,
+			DiskUsageBytes: indicesStats.All.Total.Store.SizeInBytes,
+			DiskUsage:      formatBytes(indicesStats.All.Total.Store.SizeInBytes),
+		}
 	}
 
-	return &models.ProcessedDashboardData{
-		ClusterInfo:   clusterInfo,
-		ClusterHealth: clusterHealth,
-		NodeCounts:    nodeCounts,
-		ShardCounts:   shardCounts,
-		IndexMetrics:  indexMetrics,
-	}
+	return processed
 }
 
 // GetClusterHealthByConfig fetches cluster health for a specific config
-func (s *ElasticsearchService) GetClusterHealthByConfig(connReq *models.TestConnectionRequest) (*models.ClusterHealth, error) {
-	return s.getClusterHealth(connReq)
+func (s *ElasticsearchService) GetClusterHealthByConfig(ctx context.Context, connReq *models.TestConnectionRequest) (*models.ClusterHealth, error) {
+	return s.getClusterHealth(ctx, connReq)
+}
+
+// shortHash abbreviates a build hash for logging
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
 }
 
 // formatBytes converts bytes to human readable format
@@ -458,9 +497,63 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// ExecuteRestRequest executes a generic REST request to the default Elasticsearch cluster
-func (s *ElasticsearchService) ExecuteRestRequest(config *models.Config, req *models.ElasticsearchRestRequest) (*models.ElasticsearchRestResponse, error) {
-	logging.Infof("🔍 Executing ES REST request: %s %s", req.Method, req.Endpoint)
+// ExecuteRestRequest executes a generic REST request on the cluster of the given connection
+func (s *ElasticsearchService) ExecuteRestRequest(ctx context.Context, config *models.Config, req *models.ElasticsearchRestRequest) (*models.ElasticsearchRestResponse, error) {
+	start := time.Now()
+	resp, err := s.executeRestRequest(ctx, config, req)
+	if resp != nil {
+		resp.ConfigID = config.ID
+		resp.ConnectionName = config.ConnectionName
+		resp.DurationMs = time.Since(start).Milliseconds()
+		resp.ExecutionID = executionIDFrom(ctx)
+		if s.recorder != nil {
+			s.recorder(config, req, resp)
+		}
+	}
+	return resp, err
+}
+
+// SetRequestRecorder registers the function that records every executed REST request,
+// e.g. in the request history. It must be called before requests are executed.
+func (s *ElasticsearchService) SetRequestRecorder(recorder RequestRecorder) {
+	s.recorder = recorder
+}
+
+// ExecuteRestRequestOnConfigs runs the same request on several connections concurrently
+// and returns one response per connection, in the order of configs
+func (s *ElasticsearchService) ExecuteRestRequestOnConfigs(ctx context.Context, configs []*models.Config, req *models.ElasticsearchRestRequest) []*models.ElasticsearchRestResponse {
+	logging.Infof("🔀 Running %s %s on %d connections", req.Method, req.Endpoint, len(configs))
+
+	results := make([]*models.ElasticsearchRestResponse, len(configs))
+	semaphore := make(chan struct{}, fanOutConcurrency)
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func(i int, config *models.Config) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			resp, err := s.ExecuteRestRequest(ctx, config, req)
+			if err != nil {
+				resp = &models.ElasticsearchRestResponse{
+					Success:        false,
+					StatusCode:     500,
+					ErrorDetails:   err.Error(),
+					ErrorCode:      "REQUEST_ERROR",
+					ConfigID:       config.ID,
+					ConnectionName: config.ConnectionName,
+				}
+			}
+			results[i] = resp
+		}(i, config)
+	}
+	wg.Wait()
+	return results
+}
+
+func (s *ElasticsearchService) executeRestRequest(ctx context.Context, config *models.Config, req *models.ElasticsearchRestRequest) (*models.ElasticsearchRestResponse, error) {
+	logging.Infof("🔍 Executing ES REST request on %s: %s %s", config.ConnectionName, req.Method, req.Endpoint)
 
 	// Validate the request
 	if err := req.Validate(); err != nil {
@@ -474,7 +567,31 @@ func (s *ElasticsearchService) ExecuteRestRequest(config *models.Config, req *mo
 	}
 
 	// Use the endpoint as complete URL (frontend now sends full URLs)
-	url := strings.TrimSpace(req.Endpoint)
+	url := req.EndpointWithQuery()
+
+	// Convert config to connection request for authentication
+	connReq := config.ToConnectionRequest()
+	if req.TimeoutSeconds != nil {
+		connReq.ResponseTimeoutSeconds = req.TimeoutSeconds
+	}
+	if req.MaxRetries != nil {
+		connReq.MaxRetries = req.MaxRetries
+	}
+
+	// Relative endpoints are resolved against the connection, including its path prefix.
+	// Absolute URLs must name one of the connection's nodes, or its credentials would be
+	// sent to whatever host the URL names.
+	if url != "" && !models.IsAbsoluteURL(url) {
+		url = s.buildURL(connReq, "/"+strings.TrimPrefix(url, "/"))
+	} else if url != "" && !isConfiguredURL(connReq, url) {
+		logging.Warnf("🛡️ Refusing to send credentials of %s to %s", config.ConnectionName, url)
+		return &models.ElasticsearchRestResponse{
+			Success:      false,
+			StatusCode:   400,
+			ErrorDetails: "The URL does not match the scheme, host and port of the connection's nodes; use a path such as /_cat/indices",
+			ErrorCode:    "URL_HOST_MISMATCH",
+		}, nil
+	}
 
 	// Basic URL validation
 	if url == "" {
@@ -489,14 +606,20 @@ func (s *ElasticsearchService) ExecuteRestRequest(config *models.Config, req *mo
 
 	logging.Infof("🌐 Request URL: %s", url)
 
-	// Convert config to connection request for authentication
-	connReq := &models.TestConnectionRequest{
-		Host:                 config.Host,
-		Port:                 config.Port,
-		SSLOrHTTPS:           config.SSLOrHTTPS,
-		AuthenticationMethod: config.AuthenticationMethod,
-		Username:             config.Username,
-		Password:             config.Password,
+	// Apply the connection's safety mode before anything is sent
+	blocked, err := s.checkSafetyMode(config, req, url)
+	if err != nil {
+		logging.Errorf("❌ Safety check failed: %v", err)
+		return &models.ElasticsearchRestResponse{
+			Success:      false,
+			StatusCode:   500,
+			ErrorDetails: fmt.Sprintf("Safety check failed: %v", err),
+			ErrorCode:    "SAFETY_CHECK_ERROR",
+		}, nil
+	}
+	if blocked != nil {
+		logging.Warnf("🛡️ %s request blocked by safety mode %q: %s", req.Method, config.SafetyMode, blocked.ErrorCode)
+		return blocked, nil
 	}
 
 	// Prepare request body
@@ -506,8 +629,9 @@ func (s *ElasticsearchService) ExecuteRestRequest(config *models.Config, req *mo
 		logging.Infof("📄 Request body: %s", *req.Body)
 	}
 
-	// Create HTTP request
-	httpReq, err := http.NewRequest(strings.ToUpper(req.Method), url, body)
+	// Create HTTP request, timing its phases
+	timer := &requestTimer{}
+	httpReq, err := http.NewRequestWithContext(timer.trace(ctx), strings.ToUpper(req.Method), url, body)
 	if err != nil {
 		logging.Errorf("❌ Failed to create HTTP request: %v", err)
 		return &models.ElasticsearchRestResponse{
@@ -532,20 +656,28 @@ func (s *ElasticsearchService) ExecuteRestRequest(config *models.Config, req *mo
 	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("User-Agent", "ElasticGaze/1.0")
+	for _, header := range req.Headers {
+		httpReq.Header.Del(header.Name)
+	}
+	for _, header := range req.Headers {
+		httpReq.Header.Add(header.Name, header.Value)
+	}
 
 	// Make the request
 	logging.Info("🚀 Making HTTP request...")
 	start := time.Now()
-	resp, err := s.client.Do(httpReq)
+	resp, err := s.doRequest(httpReq, connReq)
 	duration := time.Since(start)
 
 	if err != nil {
 		logging.Errorf("❌ HTTP request failed after %v: %v", duration, err)
+		errorCode, _ := classifyConnectionError(err)
 		return &models.ElasticsearchRestResponse{
 			Success:      false,
 			StatusCode:   500,
 			ErrorDetails: fmt.Sprintf("Connection failed after %v: %v", duration, err),
-			ErrorCode:    "CONNECTION_ERROR",
+			ErrorCode:    errorCode,
+			Timings:      timer.timings(),
 		}, nil
 	}
 	defer resp.Body.Close()
@@ -561,9 +693,16 @@ func (s *ElasticsearchService) ExecuteRestRequest(config *models.Config, req *mo
 			StatusCode:   resp.StatusCode,
 			ErrorDetails: fmt.Sprintf("Failed to read response: %v", err),
 			ErrorCode:    "RESPONSE_READ_ERROR",
+			Headers:      responseHeaders(resp.Header),
+			Timings:      timer.timings(),
 		}, nil
 	}
 
+	warnings := deprecationWarnings(resp.Header)
+	for _, warning := range warnings {
+		logging.Warnf("⚠️ Deprecation warning from %s: %s", config.ConnectionName, warning)
+	}
+
 	// Check if the response is successful (2xx status codes)
 	success := resp.StatusCode >= 200 && resp.StatusCode < 300
 	if !success {
@@ -573,19 +712,132 @@ func (s *ElasticsearchService) ExecuteRestRequest(config *models.Config, req *mo
 	}
 
 	return &models.ElasticsearchRestResponse{
-		Success:    success,
-		StatusCode: resp.StatusCode,
-		Response:   string(responseBody),
+		Success:     success,
+		StatusCode:  resp.StatusCode,
+		Response:    string(responseBody),
+		Headers:     responseHeaders(resp.Header),
+		ContentType: resp.Header.Get("Content-Type"),
+		SizeBytes:   int64(len(responseBody)),
+		Timings:     timer.timings(),
+		Warnings:    warnings,
 	}, nil
 }
 
+// classifyConnectionError maps a failed request to an error code and a short message,
+// distinguishing cancellations and tunnel, proxy, TLS and timeout failures from other
+// connection errors
+func classifyConnectionError(err error) (string, string) {
+	if errors.Is(err, context.Canceled) {
+		return "REQUEST_CANCELLED", "Request cancelled"
+	}
+	if errors.Is(err, errNotClusterNode) {
+		return "URL_HOST_MISMATCH", "URL is not a node of this connection"
+	}
+	if code, message, ok := classifySSHError(err); ok {
+		return code, message
+	}
+	if code, message, ok := classifyProxyError(err); ok {
+		return code, message
+	}
+	if code, message, ok := classifyTLSError(err); ok {
+		return code, message
+	}
+	if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+		return "TIMEOUT_ERROR", "Connection timeout"
+	}
+	return "CONNECTION_ERROR", "Connection failed"
+}
+
 // buildURL constructs the full URL for Elasticsearch API calls
 func (s *ElasticsearchService) buildURL(connReq *models.TestConnectionRequest, endpoint string) string {
 	scheme := "http"
 	if connReq.SSLOrHTTPS {
 		scheme = "https"
 	}
-	return fmt.Sprintf("%s://%s:%s%s", scheme, connReq.Host, connReq.Port, endpoint)
+	return fmt.Sprintf("%s://%s:%s%s%s", scheme, connReq.Host, connReq.Port, normalizePathPrefix(derefString(connReq.PathPrefix)), endpoint)
+}
+
+// normalizePathPrefix turns "es", "/es/" or "es/" into "/es" so it can be joined with endpoints
+func normalizePathPrefix(prefix string) string {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// applyDefaultHeaders adds the connection's default headers to a request.
+// Headers already set on the request take precedence.
+func applyDefaultHeaders(req *http.Request, connReq *models.TestConnectionRequest) {
+	for _, header := range connReq.DefaultHeaders {
+		if req.Header.Get(header.Name) == "" {
+			req.Header.Set(header.Name, header.Value)
+		}
+	}
+}
+
+// doRequest sends the request to the cluster within the connection's response timeout,
+// unless the request already carries a deadline. The timeout covers reading the body
+// and is released when the caller closes it.
+func (s *ElasticsearchService) doRequest(req *http.Request, connReq *models.TestConnectionRequest) (*http.Response, error) {
+	if _, ok := req.Context().Deadline(); ok {
+		return s.exchange(req, connReq)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), responseTimeout(connReq))
+	resp, err := s.exchange(req.WithContext(ctx), connReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// exchange sends the request to a live node of the cluster, retrying idempotent requests
+// the cluster is too busy for, and for OAuth2 connections transparently refreshes the
+// access token and retries once when Elasticsearch answers 401
+func (s *ElasticsearchService) exchange(req *http.Request, connReq *models.TestConnectionRequest) (*http.Response, error) {
+	client, err := s.clientFor(connReq)
+	if err != nil {
+		return nil, err
+	}
+
+	applyDefaultHeaders(req, connReq)
+	if id := executionIDFrom(req.Context()); id != "" && req.Header.Get(opaqueIDHeader) == "" {
+		req.Header.Set(opaqueIDHeader, id)
+	}
+
+	resp, err := s.sendWithRetry(client, req, connReq)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		s.forgetCredentials(connReq)
+	}
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || connReq.AuthenticationMethod != "oauth2" {
+		return resp, err
+	}
+
+	if req.Body != nil && req.GetBody == nil {
+		// The body has been consumed and cannot be replayed
+		return resp, nil
+	}
+
+	logging.Info("🔄 Received 401, refreshing OAuth2 token")
+	rejected := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if err := s.refreshOAuthToken(req.Context(), connReq, rejected); err != nil {
+		logging.Warnf("⚠️ OAuth2 token refresh failed: %v", err)
+		return resp, nil
+	}
+
+	retry, err := cloneRequest(req)
+	if err != nil {
+		return resp, nil
+	}
+	if err := s.addAuthentication(retry, connReq); err != nil {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	return s.sendToCluster(client, retry, connReq)
 }
 
 // addAuthentication adds authentication headers to the HTTP request
@@ -595,13 +847,43 @@ func (s *ElasticsearchService) addAuthentication(req *http.Request, connReq *mod
 		if connReq.Username == nil || connReq.Password == nil {
 			return fmt.Errorf("username and password required for basic authentication")
 		}
-		req.SetBasicAuth(*connReq.Username, *connReq.Password)
+		password, err := s.credential(req.Context(), *connReq.Password)
+		if err != nil {
+			return fmt.Errorf("failed to resolve password: %w", err)
+		}
+		req.SetBasicAuth(*connReq.Username, password)
 
 	case "apikey":
-		if connReq.APIKey == nil {
+		if connReq.APIKey == nil || strings.TrimSpace(*connReq.APIKey) == "" {
 			return fmt.Errorf("API key required for API key authentication")
 		}
-		req.Header.Set("Authorization", "ApiKey "+*connReq.APIKey)
+		apiKey, err := s.credential(req.Context(), *connReq.APIKey)
+		if err != nil {
+			return fmt.Errorf("failed to resolve API key: %w", err)
+		}
+		req.Header.Set("Authorization", "ApiKey "+encodeAPIKey(apiKey))
+
+	case "bearer":
+		if connReq.BearerToken == nil || strings.TrimSpace(*connReq.BearerToken) == "" {
+			return fmt.Errorf("token required for bearer authentication")
+		}
+		token, err := s.credential(req.Context(), *connReq.BearerToken)
+		if err != nil {
+			return fmt.Errorf("failed to resolve token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
+
+	case "oauth2":
+		accessToken, err := s.oauthAccessToken(req.Context(), connReq)
+		if err != nil {
+			return fmt.Errorf("failed to obtain OAuth2 token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	case "aws_sigv4":
+		if err := addSigV4Authentication(req, connReq); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
 
 	case "none":
 		// No authentication needed
@@ -612,3 +894,89 @@ func (s *ElasticsearchService) addAuthentication(req *http.Request, connReq *mod
 
 	return nil
 }
+
+// encodeAPIKey returns the base64 "encoded" form Elasticsearch expects in the
+// Authorization header. Keys may be stored either as "id:api_key" or already encoded;
+// the encoded form never contains a colon since it is standard base64.
+func encodeAPIKey(apiKey string) string {
+	apiKey = strings.TrimSpace(apiKey)
+	if strings.Contains(apiKey, ":") {
+		return base64.StdEncoding.EncodeToString([]byte(apiKey))
+	}
+	return apiKey
+}
+
+// clusterProfile returns the connection's stored capability profile, detecting it
+// from the root endpoint when the connection has not been profiled yet
+func (s *ElasticsearchService) clusterProfile(ctx context.Context, connReq *models.TestConnectionRequest) (*models.ClusterProfile, error) {
+	if connReq.ClusterProfile != nil {
+		return connReq.ClusterProfile, nil
+	}
+
+	clusterInfo, err := s.getClusterInfo(ctx, connReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect cluster version: %w", err)
+	}
+	connReq.ClusterProfile = models.NewClusterProfile(clusterInfo)
+	return connReq.ClusterProfile, nil
+}
+
+// GetLifecyclePolicies returns the index lifecycle policies of a cluster as raw JSON,
+// using ILM on Elasticsearch and ISM on OpenSearch
+func (s *ElasticsearchService) GetLifecyclePolicies(ctx context.Context, config *models.Config) (string, error) {
+	connReq := config.ToConnectionRequest()
+
+	profile, err := s.clusterProfile(ctx, connReq)
+	if err != nil {
+		return "", err
+	}
+
+	path, ok := profile.LifecyclePoliciesPath()
+	if !ok {
+		return "", fmt.Errorf("index lifecycle policies are not supported by %s %s (%s)", profile.Distribution, profile.Version, profile.BuildFlavor)
+	}
+
+	return s.getJSON(ctx, connReq, path)
+}
+
+// GetAuthenticatedUser returns the user the connection authenticates as, as raw JSON,
+// using the security endpoint of the cluster's distribution
+func (s *ElasticsearchService) GetAuthenticatedUser(ctx context.Context, config *models.Config) (string, error) {
+	connReq := config.ToConnectionRequest()
+
+	profile, err := s.clusterProfile(ctx, connReq)
+	if err != nil {
+		return "", err
+	}
+
+	return s.getJSON(ctx, connReq, profile.AuthenticatePath())
+}
+
+// getJSON performs an authenticated GET and returns the response body of a 200 response
+func (s *ElasticsearchService) getJSON(ctx context.Context, connReq *models.TestConnectionRequest, endpoint string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.buildURL(connReq, endpoint), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := s.addAuthentication(req, connReq); err != nil {
+		return "", fmt.Errorf("failed to add authentication: %w", err)
+	}
+
+	resp, err := s.doRequest(req, connReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	return string(body), nil
+}
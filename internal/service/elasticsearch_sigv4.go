@@ -0,0 +1,349 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"elasticgaze/internal/models"
+)
+
+const (
+	sigv4Algorithm  = "AWS4-HMAC-SHA256"
+	sigv4TimeFormat = "20060102T150405Z"
+	sigv4DateFormat = "20060102"
+
+	// awsDefaultService is the signing name of Amazon OpenSearch Service domains;
+	// OpenSearch Serverless collections use "aoss"
+	awsDefaultService = "es"
+	awsDefaultProfile = "default"
+)
+
+// awsCredentials are the keys used to sign a request
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// resolveAWSCredentials returns the connection's static keys when set, otherwise the
+// named profile from the shared credentials file, otherwise the standard AWS_* environment variables
+func resolveAWSCredentials(connReq *models.TestConnectionRequest) (*awsCredentials, error) {
+	if accessKey := derefString(connReq.AWSAccessKeyID); accessKey != "" {
+		return &awsCredentials{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: derefString(connReq.AWSSecretAccessKey),
+			SessionToken:    derefString(connReq.AWSSessionToken),
+		}, nil
+	}
+
+	if profile := derefString(connReq.AWSProfile); profile != "" {
+		return loadAWSProfile(profile)
+	}
+
+	if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+		return &awsCredentials{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = awsDefaultProfile
+	}
+	return loadAWSProfile(profile)
+}
+
+// loadAWSProfile reads a profile from ~/.aws/credentials, or from AWS_SHARED_CREDENTIALS_FILE when set
+func loadAWSProfile(profile string) (*awsCredentials, error) {
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate AWS credentials file: %w", err)
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+
+	file, err := os.Open(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open AWS credentials file: %w", err)
+	}
+	defer file.Close()
+
+	creds := &awsCredentials{}
+	found := false
+	inProfile := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inProfile = strings.TrimSpace(line[1:len(line)-1]) == profile
+			found = found || inProfile
+			continue
+		}
+		if !inProfile {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = strings.TrimSpace(value)
+		case "aws_session_token":
+			creds.SessionToken = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read AWS credentials file: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("AWS profile %q not found in %s", profile, path)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS profile %q has no access keys", profile)
+	}
+	return creds, nil
+}
+
+// addSigV4Authentication signs the request for Amazon OpenSearch Service
+func addSigV4Authentication(req *http.Request, connReq *models.TestConnectionRequest) error {
+	region := strings.TrimSpace(derefString(connReq.AWSRegion))
+	if region == "" {
+		return fmt.Errorf("AWS region required for SigV4 authentication")
+	}
+	service := strings.TrimSpace(derefString(connReq.AWSService))
+	if service == "" {
+		service = awsDefaultService
+	}
+
+	creds, err := resolveAWSCredentials(connReq)
+	if err != nil {
+		return err
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return fmt.Errorf("AWS access key ID and secret access key required for SigV4 authentication")
+	}
+
+	body, err := readReplayableBody(req)
+	if err != nil {
+		return err
+	}
+
+	return signSigV4(req, body, creds, region, service, time.Now())
+}
+
+// readReplayableBody returns the request body and makes sure it can still be sent afterwards
+func readReplayableBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// signSigV4 adds the AWS Signature Version 4 headers to a request. The signature covers the
+// method, path, query, the host and x-amz-* headers, and the SHA-256 hash of the body.
+func signSigV4(req *http.Request, body []byte, creds *awsCredentials, region, service string, now time.Time) error {
+	now = now.UTC()
+	payloadHash := sha256.Sum256(body)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+
+	// AWS signs the host without a default port, so send it the same way
+	req.Host = sigv4Host(req)
+	req.Header.Set("X-Amz-Date", now.Format(sigv4TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHex)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	} else {
+		req.Header.Del("X-Amz-Security-Token")
+	}
+
+	req.Header.Set("Authorization", sigv4Authorization(req, payloadHex, creds, region, service, now))
+	return nil
+}
+
+// sigv4Authorization computes the Authorization header value for a request whose host
+// and x-amz-* headers are already set, signing them as they are
+func sigv4Authorization(req *http.Request, payloadHex string, creds *awsCredentials, region, service string, now time.Time) string {
+	now = now.UTC()
+	amzDate := now.Format(sigv4TimeFormat)
+	date := now.Format(sigv4DateFormat)
+
+	signedHeaders, canonicalHeaders := sigv4CanonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigv4CanonicalURI(req.URL),
+		sigv4CanonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHex,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigv4Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigv4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature)
+}
+
+// sigv4Host returns the request host without the scheme's default port
+func sigv4Host(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+	if (req.URL.Scheme == "https" && port == "443") || (req.URL.Scheme == "http" && port == "80") {
+		if strings.Contains(hostname, ":") {
+			return "[" + hostname + "]"
+		}
+		return hostname
+	}
+	return host
+}
+
+// sigv4CanonicalHeaders returns the signed header list and the canonical header block.
+// Only host and x-amz-* headers are signed, so headers added after signing do not break it.
+func sigv4CanonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if !strings.HasPrefix(lower, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[lower] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// sigv4CanonicalURI encodes the escaped request path once more, as AWS expects for every service but S3
+func sigv4CanonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigv4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigv4CanonicalQuery sorts the encoded query parameters by name, then value, and joins them
+func sigv4CanonicalQuery(u *url.URL) string {
+	type pair struct{ name, value string }
+
+	var pairs []pair
+	for name, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, pair{sigv4Escape(name), sigv4Escape(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].name != pairs[j].name {
+			return pairs[i].name < pairs[j].name
+		}
+		return pairs[i].value < pairs[j].value
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, p := range pairs {
+		encoded[i] = p.name + "=" + p.value
+	}
+	return strings.Join(encoded, "&")
+}
+
+// sigv4Escape percent-encodes everything except the RFC 3986 unreserved characters
+func sigv4Escape(value string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(value) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' {
+			escaped.WriteByte(b)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+// hmacSHA256 computes an HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"elasticgaze/internal/models"
+)
+
+// Credentials, scope and time of the AWS Signature Version 4 test suite
+var sigv4TestCredentials = &awsCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+var sigv4TestTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSigV4Authorization(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		signature string
+	}{
+		{"get-vanilla", "GET", "/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-empty-query-key", "GET", "/?Param1=value1", "a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb"},
+		{"get-vanilla-query-order-key-case", "GET", "/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"get-vanilla-query-order-value", "GET", "/?Param1=value2&Param1=value1", "5772eed61e12b33fae39ee5e7012498b51d56abc0abb7c60486157bd471c4694"},
+		{"get-unreserved", "GET", "/-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", "07ef7494c76fa4850883e2b006601f940f8a34d404d0cfa977f52a65bbf5f24f"},
+		{"post-vanilla", "POST", "/", "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{"post-vanilla-query", "POST", "/?Param1=value1", "28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11"},
+	}
+
+	emptyHash := sha256.Sum256(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "https://example.amazonaws.com"+tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Amz-Date", "20150830T123600Z")
+
+			got := sigv4Authorization(req, hex.EncodeToString(emptyHash[:]), sigv4TestCredentials, "us-east-1", "service", sigv4TestTime)
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tt.signature
+			if got != want {
+				t.Errorf("Authorization = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSignSigV4Headers(t *testing.T) {
+	req, err := http.NewRequest("PUT", "https://search.example.com:443/logs/_doc/1", strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := *sigv4TestCredentials
+	creds.SessionToken = "token"
+
+	if err := signSigV4(req, []byte(`{"a":1}`), &creds, "eu-west-1", "es", sigv4TestTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Host != "search.example.com" {
+		t.Errorf("Host = %q, want the host without the default port", req.Host)
+	}
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q, want %q", got, "token")
+	}
+	bodyHash := sha256.Sum256([]byte(`{"a":1}`))
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(bodyHash[:]) {
+		t.Errorf("X-Amz-Content-Sha256 = %q", got)
+	}
+	if got := req.Header.Get("Authorization"); !strings.Contains(got, "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Authorization = %q, want the host and x-amz-* headers signed", got)
+	}
+}
+
+func TestSendToClusterResignsForAnotherNode(t *testing.T) {
+	var gotHost, gotAuthorization, gotDate string
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost, gotAuthorization, gotDate = r.Host, r.Header.Get("Authorization"), r.Header.Get("X-Amz-Date")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer live.Close()
+
+	// A closed listener refuses connections, so the first node fails to dial
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadHost, deadPort, _ := net.SplitHostPort(dead.Addr().String())
+	dead.Close()
+
+	connReq := &models.TestConnectionRequest{
+		Host:                 deadHost,
+		Port:                 deadPort,
+		AuthenticationMethod: "aws_sigv4",
+		Nodes:                []string{live.URL},
+		AWSRegion:            models.StringPtr("us-east-1"),
+		AWSAccessKeyID:       models.StringPtr(sigv4TestCredentials.AccessKeyID),
+		AWSSecretAccessKey:   models.StringPtr(sigv4TestCredentials.SecretAccessKey),
+	}
+
+	s := NewElasticsearchService()
+	req, err := http.NewRequest("GET", "http://"+net.JoinHostPort(deadHost, deadPort)+"/_cluster/health", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.addAuthentication(req, connReq); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.sendToCluster(http.DefaultClient, req, connReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	liveURL, _ := url.Parse(live.URL)
+	if gotHost != liveURL.Host {
+		t.Fatalf("Host = %q, want the live node %q", gotHost, liveURL.Host)
+	}
+
+	date, err := time.Parse(sigv4TimeFormat, gotDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := http.NewRequest("GET", live.URL+"/_cluster/health", nil)
+	expected.Host = liveURL.Host
+	expected.Header.Set("X-Amz-Date", gotDate)
+	emptyHash := sha256.Sum256(nil)
+	expected.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(emptyHash[:]))
+	want := sigv4Authorization(expected, hex.EncodeToString(emptyHash[:]), sigv4TestCredentials, "us-east-1", awsDefaultService, date)
+	if gotAuthorization != want {
+		t.Errorf("Authorization = %q, want a signature for the live node %q", gotAuthorization, want)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/models"
+)
+
+// requestTimer records the phases of a request through httptrace. Retries, token
+// refreshes and redirects run with the same trace, so every new connection attempt
+// starts the measurement over and the timings describe the last one.
+type requestTimer struct {
+	mu sync.Mutex
+	requestPhases
+}
+
+// requestPhases are the measurements of one connection attempt
+type requestPhases struct {
+	start        time.Time
+	dnsStart     time.Time
+	dns          time.Duration
+	connectStart time.Time
+	connect      time.Duration
+	tlsStart     time.Time
+	tls          time.Duration
+	firstByte    time.Duration
+	reused       bool
+}
+
+// trace attaches the timer to ctx
+func (t *requestTimer) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.requestPhases = requestPhases{start: time.Now()}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dns = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now() // Dual-stack dialing may start several attempts
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil {
+				t.connect = time.Since(t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tls = time.Since(t.tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Since(t.start)
+		},
+	})
+}
+
+// timings reports the recorded phases, measuring the total up to now
+func (t *requestTimer) timings() *models.RequestTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.start.IsZero() {
+		return nil // The request never asked for a connection
+	}
+	return &models.RequestTimings{
+		DNSMs:            milliseconds(t.dns),
+		ConnectMs:        milliseconds(t.connect),
+		TLSMs:            milliseconds(t.tls),
+		TTFBMs:           milliseconds(t.firstByte),
+		TotalMs:          milliseconds(time.Since(t.start)),
+		ConnectionReused: t.reused,
+	}
+}
+
+// milliseconds converts a duration to milliseconds with microsecond precision
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// responseHeaders lists the headers of a response sorted by name, one entry per value
+func responseHeaders(header http.Header) []models.HTTPHeader {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers []models.HTTPHeader
+	for _, name := range names {
+		for _, value := range header[name] {
+			headers = append(headers, models.HTTPHeader{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// deprecationWarnings extracts the messages of the Warning headers Elasticsearch sends for
+// deprecated APIs, settings and parameters. A header looks like
+//
+//	299 Elasticsearch-8.12.0-abc123 "[types removal] Specifying types in search requests is deprecated."
+//
+// and a value that does not follow that format is returned as it is.
+func deprecationWarnings(header http.Header) []string {
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, value := range header.Values("Warning") {
+		message := warningText(value)
+		if message != "" && !seen[message] {
+			seen[message] = true
+			warnings = append(warnings, message)
+		}
+	}
+	return warnings
+}
+
+// warningText returns the quoted text of a Warning header value
+func warningText(value string) string {
+	value = strings.TrimSpace(value)
+	open := strings.IndexByte(value, '"')
+	if open < 0 {
+		return value
+	}
+
+	var text strings.Builder
+	for i := open + 1; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\':
+			if i+1 < len(value) {
+				i++
+				text.WriteByte(value[i])
+			}
+		case '"':
+			return text.String() // An optional quoted date may follow
+		default:
+			text.WriteByte(c)
+		}
+	}
+	return value // Unterminated quote
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+// tokenExpirySkew refreshes access tokens slightly before Elasticsearch expires them
+const tokenExpirySkew = 30 * time.Second
+
+// oauthToken holds an access token minted through /_security/oauth2/token
+type oauthToken struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// expired reports whether the access token should no longer be used
+func (t *oauthToken) expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().Add(tokenExpirySkew).After(t.ExpiresAt)
+}
+
+// tokenResponse is the body returned by the Elasticsearch get token API
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	Type         string `json:"type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// tokenStore keeps OAuth2 tokens per connection in memory so refresh tokens never hit disk.
+// mu only guards the maps; token requests run without it, one at a time per connection.
+type tokenStore struct {
+	mu       sync.Mutex
+	tokens   map[string]*oauthToken
+	fetching map[string]*tokenFetch
+}
+
+// tokenFetch is a token request in flight; done is closed once token or err is set
+type tokenFetch struct {
+	done  chan struct{}
+	token *oauthToken
+	err   error
+}
+
+func newTokenStore() *tokenStore {
+	return &tokenStore{tokens: make(map[string]*oauthToken), fetching: make(map[string]*tokenFetch)}
+}
+
+// tokenKey identifies the cluster and user a token was minted for
+func (s *ElasticsearchService) tokenKey(connReq *models.TestConnectionRequest) string {
+	username := ""
+	if connReq.Username != nil {
+		username = *connReq.Username
+	}
+	return s.buildURL(connReq, "") + "|" + username
+}
+
+// oauthAccessToken returns a valid access token for the connection, minting or
+// refreshing one when the cached token is missing or expired. Callers that need a new
+// token at the same time share one token request.
+func (s *ElasticsearchService) oauthAccessToken(ctx context.Context, connReq *models.TestConnectionRequest) (string, error) {
+	key := s.tokenKey(connReq)
+
+	s.tokens.mu.Lock()
+	token := s.tokens.tokens[key]
+	if token != nil && !token.expired() {
+		s.tokens.mu.Unlock()
+		return token.AccessToken, nil
+	}
+
+	fetch, fetching := s.tokens.fetching[key]
+	if !fetching {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		s.tokens.fetching[key] = fetch
+	}
+	s.tokens.mu.Unlock()
+
+	if !fetching {
+		// Other callers may be waiting for the token, so one caller giving up does not cancel it
+		go s.fetchToken(context.WithoutCancel(ctx), key, connReq, token, fetch)
+	}
+
+	select {
+	case <-fetch.done:
+		if fetch.err != nil {
+			return "", fetch.err
+		}
+		return fetch.token.AccessToken, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// fetchToken replaces the cached token of a tokenFetch, using the refresh token of the
+// current one when there is one and the stored credentials otherwise
+func (s *ElasticsearchService) fetchToken(ctx context.Context, key string, connReq *models.TestConnectionRequest, current *oauthToken, fetch *tokenFetch) {
+	defer close(fetch.done)
+
+	var token *oauthToken
+	var err error
+	if current != nil && current.RefreshToken != "" {
+		token, err = s.requestToken(ctx, connReq, map[string]string{
+			"grant_type":    "refresh_token",
+			"refresh_token": current.RefreshToken,
+		})
+		if err != nil {
+			logging.Warnf("⚠️ OAuth2 token refresh failed, requesting a new token: %v", err)
+		}
+	}
+	if token == nil {
+		token, err = s.requestPasswordToken(ctx, connReq)
+	}
+
+	s.tokens.mu.Lock()
+	defer s.tokens.mu.Unlock()
+
+	delete(s.tokens.fetching, key)
+	if err != nil {
+		delete(s.tokens.tokens, key)
+		fetch.err = err
+		return
+	}
+	s.tokens.tokens[key] = token
+	fetch.token = token
+}
+
+// refreshOAuthToken is called after a 401 response to a request sent with rejected, the
+// access token it carried. The cached token is replaced only while it is still that one,
+// so concurrent 401s do not each spend the refresh token another caller just used.
+func (s *ElasticsearchService) refreshOAuthToken(ctx context.Context, connReq *models.TestConnectionRequest, rejected string) error {
+	key := s.tokenKey(connReq)
+
+	s.tokens.mu.Lock()
+	if token := s.tokens.tokens[key]; token != nil && token.AccessToken == rejected {
+		// Mark as expired so oauthAccessToken goes through the refresh path
+		token.ExpiresAt = time.Unix(1, 0)
+	}
+	s.tokens.mu.Unlock()
+
+	_, err := s.oauthAccessToken(ctx, connReq)
+	return err
+}
+
+// requestPasswordToken mints a new token pair using the connection's username and password
+func (s *ElasticsearchService) requestPasswordToken(ctx context.Context, connReq *models.TestConnectionRequest) (*oauthToken, error) {
+	if connReq.Username == nil || connReq.Password == nil {
+		return nil, fmt.Errorf("username and password required for OAuth2 authentication")
+	}
+	password, err := s.credential(ctx, *connReq.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve password: %w", err)
+	}
+	return s.requestToken(ctx, connReq, map[string]string{
+		"grant_type": "password",
+		"username":   *connReq.Username,
+		"password":   password,
+	})
+}
+
+// requestToken calls the Elasticsearch get token API with the given grant
+func (s *ElasticsearchService) requestToken(ctx context.Context, connReq *models.TestConnectionRequest, grant map[string]string) (*oauthToken, error) {
+	payload, err := json.Marshal(grant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, responseTimeout(connReq))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.buildURL(connReq, "/_security/oauth2/token"), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ElasticGaze/1.0")
+	applyDefaultHeaders(req, connReq)
+
+	// The get token API must itself be called by an authenticated user
+	if connReq.Username != nil && connReq.Password != nil {
+		password, err := s.credential(ctx, *connReq.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve password: %w", err)
+		}
+		req.SetBasicAuth(*connReq.Username, password)
+	}
+
+	client, err := s.clientFor(connReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sent through the node pool like any other request, so a dead node is failed over
+	resp, err := s.sendToCluster(client, req, connReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp tokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("token response did not contain an access token")
+	}
+
+	token := &oauthToken{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+	}
+	if tokenResp.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+
+	logging.Infof("🔑 Obtained OAuth2 access token (expires in %ds)", tokenResp.ExpiresIn)
+	return token, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"elasticgaze/internal/models"
+)
+
+// oauthTestCluster answers the get token API and rejects every access token but the latest
+type oauthTestCluster struct {
+	*httptest.Server
+	refreshes atomic.Int32
+	issued    atomic.Int32
+	block     chan struct{} // When set, token requests wait for it to close
+}
+
+func newOAuthTestCluster(t *testing.T) *oauthTestCluster {
+	c := &oauthTestCluster{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_security/oauth2/token" {
+			if c.block != nil {
+				<-c.block
+			}
+			var grant map[string]string
+			json.NewDecoder(r.Body).Decode(&grant)
+			if grant["grant_type"] == "refresh_token" {
+				c.refreshes.Add(1)
+			}
+			n := c.issued.Add(1)
+			json.NewEncoder(w).Encode(tokenResponse{AccessToken: fmt.Sprintf("token-%d", n), RefreshToken: fmt.Sprintf("refresh-%d", n), ExpiresIn: 3600})
+			return
+		}
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", c.issued.Load()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *oauthTestCluster) connection() *models.TestConnectionRequest {
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(c.URL, "http://"))
+	return &models.TestConnectionRequest{
+		Host:                 host,
+		Port:                 port,
+		AuthenticationMethod: "oauth2",
+		Username:             models.StringPtr("elastic"),
+		Password:             models.StringPtr("changeme"),
+	}
+}
+
+func TestOAuthConcurrent401sRefreshOnce(t *testing.T) {
+	cluster := newOAuthTestCluster(t)
+	connReq := cluster.connection()
+	s := NewElasticsearchService()
+
+	if _, err := s.oauthAccessToken(context.Background(), connReq); err != nil {
+		t.Fatal(err)
+	}
+	// Another client revokes the cached token by minting a new one
+	cluster.issued.Add(1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", cluster.URL+"/_cluster/health", nil)
+			if err := s.addAuthentication(req, connReq); err != nil {
+				t.Error(err)
+				return
+			}
+			resp, err := s.doRequest(req, connReq)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200 after the refresh", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := cluster.refreshes.Load(); got != 1 {
+		t.Errorf("refreshed the token %d times, want once", got)
+	}
+}
+
+func TestOAuthSlowClusterDoesNotBlockOthers(t *testing.T) {
+	slow := newOAuthTestCluster(t)
+	slow.block = make(chan struct{})
+	defer close(slow.block)
+	fast := newOAuthTestCluster(t)
+	s := NewElasticsearchService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.oauthAccessToken(ctx, slow.connection())
+	defer cancel()
+	time.Sleep(50 * time.Millisecond) // Let the slow token request start
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.oauthAccessToken(context.Background(), fast.connection())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a token request to another cluster waited for the slow one")
+	}
+}
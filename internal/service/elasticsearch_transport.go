@@ -0,0 +1,257 @@
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+// maxRedirects matches the limit of net/http's default redirect policy
+const maxRedirects = 10
+
+// tlsConfigError marks failures to build a connection's TLS settings, such as an unreadable CA file
+type tlsConfigError struct {
+	err error
+}
+
+func (e *tlsConfigError) Error() string {
+	return fmt.Sprintf("invalid TLS configuration: %v", e.err)
+}
+
+func (e *tlsConfigError) Unwrap() error {
+	return e.err
+}
+
+// fingerprintMismatchError is returned when no certificate presented by the server matches the pinned fingerprint
+type fingerprintMismatchError struct {
+	expected string
+}
+
+func (e *fingerprintMismatchError) Error() string {
+	return fmt.Sprintf("no certificate in the server chain matches the pinned fingerprint %s", e.expected)
+}
+
+// clientFor returns the HTTP client for a connection, building and caching one per
+// distinct set of transport settings so each cluster gets its own TLS trust
+func (s *ElasticsearchService) clientFor(connReq *models.TestConnectionRequest) (*http.Client, error) {
+	key := transportKey(connReq)
+
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	if client, ok := s.clients[key]; ok {
+		return client, nil
+	}
+
+	tlsConfig, err := buildTLSConfig(connReq)
+	if err != nil {
+		return nil, &tlsConfigError{err: err}
+	}
+
+	proxy, err := buildProxyFunc(connReq)
+	if err != nil {
+		return nil, &proxyConfigError{err: err}
+	}
+
+	dialContext := s.tunnelDialer(connReq)
+	if dialContext == nil {
+		dialContext = (&net.Dialer{Timeout: connectTimeout(connReq), KeepAlive: 30 * time.Second}).DialContext
+	}
+
+	// Requests are bounded by the response timeout through their context, see doRequest
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:     tlsConfig,
+			Proxy:               proxy,
+			DialContext:         dialContext,
+			TLSHandshakeTimeout: tlsTimeout(connReq),
+		},
+		CheckRedirect: checkRedirect,
+	}
+	s.clients[key] = client
+	return client, nil
+}
+
+// checkRedirect follows redirects only to the scheme, host and port of the original
+// request. net/http keeps Authorization on redirects to subdomains and always forwards
+// custom headers, which may carry credentials too; a redirect anywhere else is not
+// followed and its response is returned to the caller instead.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if nodeKey(req.URL) != nodeKey(via[0].URL) {
+		logging.Warnf("🛡️ Not following redirect from %s to %s", via[0].URL.Host, req.URL.Host)
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+// transportKey identifies the settings that affect how connections to a cluster are made
+func transportKey(connReq *models.TestConnectionRequest) string {
+	return strings.Join([]string{
+		fmt.Sprintf("verify=%t", connReq.VerifiesTLS()),
+		"ca=" + derefString(connReq.TLSCACert),
+		"fingerprint=" + normalizeFingerprint(derefString(connReq.TLSCAFingerprint)),
+		"cert=" + derefString(connReq.TLSClientCert),
+		"key=" + derefString(connReq.TLSClientKey),
+		"proxy=" + derefString(connReq.ProxyURL),
+		"proxy_user=" + derefString(connReq.ProxyUsername),
+		"proxy_password=" + derefString(connReq.ProxyPassword),
+		"no_proxy=" + strings.Join(connReq.NoProxy, ","),
+		"ssh=" + sshKeyOf(connReq),
+		fmt.Sprintf("connect_timeout=%v", connectTimeout(connReq)),
+		fmt.Sprintf("tls_timeout=%v", tlsTimeout(connReq)),
+	}, "|")
+}
+
+// buildTLSConfig translates the connection's TLS settings into a tls.Config
+func buildTLSConfig(connReq *models.TestConnectionRequest) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	clientCert := derefString(connReq.TLSClientCert)
+	clientKey := derefString(connReq.TLSClientKey)
+	if clientCert != "" || clientKey != "" {
+		if clientCert == "" || clientKey == "" {
+			return nil, fmt.Errorf("both a client certificate and a client key are required for mutual TLS")
+		}
+		certPEM, err := loadPEM(clientCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		keyPEM, err := loadPEM(clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client key: %w", err)
+		}
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate and key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	// A pinned fingerprint replaces chain verification, the same way the official
+	// Elasticsearch clients treat the fingerprint printed on first start
+	if fingerprint := normalizeFingerprint(derefString(connReq.TLSCAFingerprint)); fingerprint != "" {
+		if _, err := hex.DecodeString(fingerprint); err != nil || len(fingerprint) != sha256.Size*2 {
+			return nil, fmt.Errorf("CA fingerprint must be a SHA-256 hex digest")
+		}
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			for _, cert := range state.PeerCertificates {
+				sum := sha256.Sum256(cert.Raw)
+				if hex.EncodeToString(sum[:]) == fingerprint {
+					return nil
+				}
+			}
+			return &fingerprintMismatchError{expected: fingerprint}
+		}
+		return tlsConfig, nil
+	}
+
+	if !connReq.VerifiesTLS() {
+		tlsConfig.InsecureSkipVerify = true
+		return tlsConfig, nil
+	}
+
+	if ca := derefString(connReq.TLSCACert); ca != "" {
+		caPEM, err := loadPEM(ca)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// loadPEM accepts either inline PEM content or a path to a PEM file
+func loadPEM(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
+
+// normalizeFingerprint lowercases a fingerprint and strips the colons and spaces
+// Elasticsearch and openssl print between byte pairs
+func normalizeFingerprint(fingerprint string) string {
+	fingerprint = strings.ToLower(strings.TrimSpace(fingerprint))
+	fingerprint = strings.ReplaceAll(fingerprint, ":", "")
+	return strings.ReplaceAll(fingerprint, " ", "")
+}
+
+// classifyTLSError maps certificate and handshake failures to dedicated error codes
+func classifyTLSError(err error) (code string, message string, ok bool) {
+	var configErr *tlsConfigError
+	var fingerprintErr *fingerprintMismatchError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+
+	switch {
+	case errors.As(err, &configErr):
+		return "TLS_CONFIG_ERROR", "Invalid TLS configuration", true
+	case errors.As(err, &fingerprintErr):
+		return "TLS_FINGERPRINT_MISMATCH", "Server certificate does not match the pinned fingerprint", true
+	case errors.As(err, &unknownAuthorityErr):
+		return "TLS_UNKNOWN_AUTHORITY", "Certificate signed by an untrusted authority", true
+	case errors.As(err, &hostnameErr):
+		return "TLS_HOSTNAME_MISMATCH", "Certificate is not valid for this host", true
+	case errors.As(err, &invalidErr):
+		if invalidErr.Reason == x509.Expired {
+			return "TLS_CERT_EXPIRED", "Certificate has expired or is not yet valid", true
+		}
+		return "TLS_CERT_INVALID", "Certificate is invalid", true
+	case errors.As(err, &recordHeaderErr):
+		return "TLS_NOT_SUPPORTED", "Server did not respond with TLS (is HTTPS enabled?)", true
+	}
+
+	errText := err.Error()
+	switch {
+	case strings.Contains(errText, "tls: bad certificate"), strings.Contains(errText, "tls: certificate required"):
+		return "TLS_CLIENT_CERT_REJECTED", "Server rejected the client certificate", true
+	case strings.Contains(errText, "tls:"):
+		return "TLS_HANDSHAKE_ERROR", "TLS handshake failed", true
+	}
+
+	return "", "", false
+}
+
+// derefString returns the pointed-to string or an empty string for nil
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// sshKeyOf identifies the connection's SSH tunnel settings, or returns an empty string without a tunnel
+func sshKeyOf(connReq *models.TestConnectionRequest) string {
+	if settings := sshSettingsFor(connReq); settings != nil {
+		return settings.key()
+	}
+	return ""
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+)
+
+const (
+	// sshDefaultPort is used when the bastion host has no explicit port
+	sshDefaultPort = "22"
+	// sshDialTimeout bounds connecting and authenticating to the bastion
+	sshDialTimeout = 15 * time.Second
+)
+
+// sshTunnelError marks failures to open the SSH tunnel, as opposed to failures reaching the cluster
+type sshTunnelError struct {
+	err error
+}
+
+func (e *sshTunnelError) Error() string {
+	return fmt.Sprintf("SSH tunnel failed: %v", e.err)
+}
+
+func (e *sshTunnelError) Unwrap() error {
+	return e.err
+}
+
+// sshSettings are the bastion settings of a connection, copied out of the request
+// so a cached transport never depends on a caller's request
+type sshSettings struct {
+	address        string
+	user           string
+	keyFile        string
+	keyPassphrase  string
+	password       string
+	knownHostsFile string
+	ignoreHostKey  bool
+}
+
+// sshSettingsFor returns the connection's bastion settings, or nil when it does not use a tunnel
+func sshSettingsFor(connReq *models.TestConnectionRequest) *sshSettings {
+	host := strings.TrimSpace(derefString(connReq.SSHHost))
+	if host == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, sshDefaultPort)
+	}
+
+	return &sshSettings{
+		address:        host,
+		user:           derefString(connReq.SSHUser),
+		keyFile:        derefString(connReq.SSHKeyFile),
+		keyPassphrase:  derefString(connReq.SSHKeyPassphrase),
+		password:       derefString(connReq.SSHPassword),
+		knownHostsFile: derefString(connReq.SSHKnownHostsFile),
+		ignoreHostKey:  connReq.SSHIgnoreHostKey,
+	}
+}
+
+// key identifies a tunnel so every request using the same bastion shares it
+func (c *sshSettings) key() string {
+	return strings.Join([]string{
+		c.address,
+		c.user,
+		c.keyFile,
+		c.keyPassphrase,
+		c.password,
+		c.knownHostsFile,
+		fmt.Sprintf("%t", c.ignoreHostKey),
+	}, "|")
+}
+
+// clientConfig builds the SSH client configuration with key or password auth and host key checking
+func (c *sshSettings) clientConfig() (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+
+	if c.keyFile != "" {
+		keyPEM, err := loadPEM(expandHome(c.keyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		var signer ssh.Signer
+		if c.keyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(keyPEM, []byte(c.keyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(keyPEM)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	if c.password != "" {
+		password := c.password
+		auth = append(auth, ssh.Password(password), ssh.KeyboardInteractive(
+			func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}))
+	}
+
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("an SSH key file or password is required")
+	}
+
+	hostKeyCallback, err := c.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	}, nil
+}
+
+// hostKeyCallback checks the bastion against known_hosts unless checking was explicitly turned off
+func (c *sshSettings) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if c.ignoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	path := c.knownHostsFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate known_hosts: %w", err)
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	callback, err := knownhosts.New(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// expandHome expands a leading "~/" to the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// sshTunnel is an SSH connection to a bastion with local port-forwards to cluster nodes
+type sshTunnel struct {
+	client   *ssh.Client
+	mu       sync.Mutex
+	forwards map[string]net.Listener // Local listeners keyed by the remote "host:port" they forward to
+	done     chan struct{}
+}
+
+// tunnelManager shares tunnels between requests and tears them down on shutdown
+type tunnelManager struct {
+	mu      sync.Mutex
+	tunnels map[string]*sshTunnel
+	dialing map[string]*tunnelDial // Connections in progress, shared by every request for the same bastion
+	closed  bool
+}
+
+// tunnelDial is an SSH connection being opened; done is closed once tunnel or err is set
+type tunnelDial struct {
+	done   chan struct{}
+	tunnel *sshTunnel
+	err    error
+}
+
+func newTunnelManager() *tunnelManager {
+	return &tunnelManager{tunnels: make(map[string]*sshTunnel), dialing: make(map[string]*tunnelDial)}
+}
+
+// tunnelFor returns the open tunnel for the bastion settings, connecting a new one
+// when none exists yet or the previous one was closed by the server. The connection is
+// made outside the lock, so a slow bastion only holds up the requests that use it.
+func (m *tunnelManager) tunnelFor(ctx context.Context, settings *sshSettings) (*sshTunnel, error) {
+	key := settings.key()
+
+	m.mu.Lock()
+	if tunnel, ok := m.tunnels[key]; ok {
+		select {
+		case <-tunnel.done:
+			delete(m.tunnels, key)
+		default:
+			m.mu.Unlock()
+			return tunnel, nil
+		}
+	}
+
+	dial, dialing := m.dialing[key]
+	if !dialing {
+		dial = &tunnelDial{done: make(chan struct{})}
+		m.dialing[key] = dial
+	}
+	m.mu.Unlock()
+
+	if !dialing {
+		go m.connect(key, settings, dial)
+	}
+
+	select {
+	case <-dial.done:
+		return dial.tunnel, dial.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// connect opens the SSH connection for a tunnelDial and registers the tunnel
+func (m *tunnelManager) connect(key string, settings *sshSettings, dial *tunnelDial) {
+	defer close(dial.done)
+
+	tunnel, err := settings.connect()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.dialing, key)
+	if err == nil && m.closed {
+		tunnel.client.Close()
+		tunnel.shutdown()
+		err = &sshTunnelError{err: errors.New("tunnel is closed")}
+	}
+	if err != nil {
+		dial.err = err
+		return
+	}
+	m.tunnels[key] = tunnel
+	dial.tunnel = tunnel
+}
+
+// connect dials and authenticates to the bastion
+func (c *sshSettings) connect() (*sshTunnel, error) {
+	config, err := c.clientConfig()
+	if err != nil {
+		return nil, &sshTunnelError{err: err}
+	}
+
+	logging.Infof("🔐 Opening SSH tunnel via %s@%s", c.user, c.address)
+	conn, err := net.DialTimeout("tcp", c.address, sshDialTimeout)
+	if err != nil {
+		return nil, &sshTunnelError{err: err}
+	}
+
+	// ssh.Dial only bounds the TCP connect; a bastion that stalls the handshake must not
+	// hold up every request waiting for this tunnel
+	conn.SetDeadline(time.Now().Add(sshDialTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, c.address, config)
+	if err != nil {
+		conn.Close()
+		return nil, &sshTunnelError{err: err}
+	}
+	conn.SetDeadline(time.Time{})
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	tunnel := &sshTunnel{
+		client:   client,
+		forwards: make(map[string]net.Listener),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		client.Wait()
+		tunnel.shutdown()
+		logging.Infof("🔌 SSH tunnel via %s closed", c.address)
+	}()
+	return tunnel, nil
+}
+
+// closeAll tears down every tunnel; connections still being opened are closed as they complete
+func (m *tunnelManager) closeAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	for key, tunnel := range m.tunnels {
+		tunnel.client.Close()
+		tunnel.shutdown()
+		delete(m.tunnels, key)
+	}
+}
+
+// localAddr returns the local address that forwards to remote through the tunnel,
+// starting a new port-forward on first use
+func (t *sshTunnel) localAddr(remote string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return "", &sshTunnelError{err: errors.New("tunnel is closed")}
+	default:
+	}
+
+	if listener, ok := t.forwards[remote]; ok {
+		return listener.Addr().String(), nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", &sshTunnelError{err: fmt.Errorf("failed to open local forward: %w", err)}
+	}
+	t.forwards[remote] = listener
+	logging.Infof("🔀 Forwarding %s to %s through SSH", listener.Addr(), remote)
+
+	go t.serve(listener, remote)
+	return listener.Addr().String(), nil
+}
+
+// serve accepts local connections and pipes each one to remote through the SSH connection
+func (t *sshTunnel) serve(listener net.Listener, remote string) {
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer local.Close()
+
+			upstream, err := t.client.Dial("tcp", remote)
+			if err != nil {
+				logging.Warnf("⚠️ SSH tunnel could not reach %s: %v", remote, err)
+				return
+			}
+			defer upstream.Close()
+
+			copied := make(chan struct{}, 2)
+			go func() {
+				io.Copy(upstream, local)
+				copied <- struct{}{}
+			}()
+			go func() {
+				io.Copy(local, upstream)
+				copied <- struct{}{}
+			}()
+			<-copied
+		}()
+	}
+}
+
+// shutdown closes the local forwards; it is safe to call more than once
+func (t *sshTunnel) shutdown() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return
+	default:
+		close(t.done)
+	}
+	for remote, listener := range t.forwards {
+		listener.Close()
+		delete(t.forwards, remote)
+	}
+}
+
+// tunnelDialer returns a DialContext that reaches every address through the
+// connection's SSH tunnel, or nil when the connection does not use one
+func (s *ElasticsearchService) tunnelDialer(connReq *models.TestConnectionRequest) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	settings := sshSettingsFor(connReq)
+	if settings == nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: connectTimeout(connReq)}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		tunnel, err := s.tunnels.tunnelFor(ctx, settings)
+		if err != nil {
+			return nil, err
+		}
+		local, err := tunnel.localAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		return dialer.DialContext(ctx, network, local)
+	}
+}
+
+// Close tears down the SSH tunnels opened for connections
+func (s *ElasticsearchService) Close() {
+	s.tunnels.closeAll()
+}
+
+// classifySSHError maps tunnel failures to dedicated error codes
+func classifySSHError(err error) (code string, message string, ok bool) {
+	var tunnelErr *sshTunnelError
+	if !errors.As(err, &tunnelErr) {
+		return "", "", false
+	}
+
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &keyErr) {
+		if len(keyErr.Want) == 0 {
+			return "SSH_HOST_KEY_UNKNOWN", "Bastion host is not in known_hosts", true
+		}
+		return "SSH_HOST_KEY_MISMATCH", "Bastion host key does not match known_hosts", true
+	}
+	if strings.Contains(err.Error(), "unable to authenticate") {
+		return "SSH_AUTH_FAILED", "SSH authentication failed", true
+	}
+	return "SSH_TUNNEL_ERROR", "SSH tunnel failed", true
+}
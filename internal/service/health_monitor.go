@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+	"elasticgaze/internal/repository"
+)
+
+// healthMonitorSettingsKey is the app setting holding the monitor configuration
+const healthMonitorSettingsKey = "health_monitor"
+
+// EventEmitter publishes an event to the frontend
+type EventEmitter func(name string, data ...interface{})
+
+// HealthObserver receives the results of every completed poll round
+type HealthObserver func(ctx context.Context, statuses []*models.ClusterHealthStatus)
+
+// HealthMonitor polls the health of every connection in the background, keeps the
+// latest result per connection and emits an event whenever a status changes
+type HealthMonitor struct {
+	configService *ConfigService
+	esService     *ElasticsearchService
+	settingsRepo  *repository.SettingsRepository
+	emit          EventEmitter
+
+	mu       sync.RWMutex
+	settings models.HealthMonitorSettings
+	statuses map[int]*models.ClusterHealthStatus
+
+	checkMu   sync.Mutex // Serializes poll rounds
+	observers []HealthObserver
+	reload    chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewHealthMonitor creates a health monitor using the stored settings, or the defaults
+// when none have been saved. emit may be nil.
+func NewHealthMonitor(configService *ConfigService, esService *ElasticsearchService, settingsRepo *repository.SettingsRepository, emit EventEmitter) *HealthMonitor {
+	settings := models.DefaultHealthMonitorSettings()
+	if settingsRepo != nil {
+		stored := settings
+		if found, err := settingsRepo.Get(healthMonitorSettingsKey, &stored); err != nil {
+			logging.Warnf("⚠️ Failed to load health monitor settings, using defaults: %v", err)
+		} else if found && stored.Validate() == nil {
+			settings = stored
+		}
+	}
+
+	return &HealthMonitor{
+		configService: configService,
+		esService:     esService,
+		settingsRepo:  settingsRepo,
+		emit:          emit,
+		settings:      settings,
+		statuses:      make(map[int]*models.ClusterHealthStatus),
+		reload:        make(chan struct{}, 1),
+	}
+}
+
+// OnCheck registers an observer called after every poll round, e.g. to evaluate alert
+// rules. Observers must be registered before Start.
+func (m *HealthMonitor) OnCheck(observer HealthObserver) {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+	m.observers = append(m.observers, observer)
+}
+
+// Start begins polling in the background until Stop is called or ctx is done
+func (m *HealthMonitor) Start(ctx context.Context) {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.mu.Unlock()
+		return // Already running
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	done := m.done
+	m.mu.Unlock()
+
+	go m.run(ctx, done)
+}
+
+// Stop ends background polling and waits for the current round to finish
+func (m *HealthMonitor) Stop() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// run polls on the configured interval, restarting the wait when the settings change
+func (m *HealthMonitor) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	logging.Info("🩺 Cluster health monitor started")
+
+	for {
+		settings := m.Settings()
+		if settings.Enabled {
+			m.CheckAll(ctx)
+		}
+
+		timer := time.NewTimer(time.Duration(settings.IntervalSeconds) * time.Second)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logging.Info("🩺 Cluster health monitor stopped")
+			return
+		case <-m.reload:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Settings returns the current monitor settings
+func (m *HealthMonitor) Settings() models.HealthMonitorSettings {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.settings
+}
+
+// UpdateSettings validates, stores and applies new monitor settings. A running monitor
+// polls again straight away with the new interval and timeout.
+func (m *HealthMonitor) UpdateSettings(settings models.HealthMonitorSettings) (models.HealthMonitorSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return models.HealthMonitorSettings{}, fmt.Errorf("validation error: %w", err)
+	}
+
+	if m.settingsRepo != nil {
+		if err := m.settingsRepo.Set(healthMonitorSettingsKey, settings); err != nil {
+			return models.HealthMonitorSettings{}, err
+		}
+	}
+
+	m.mu.Lock()
+	m.settings = settings
+	m.mu.Unlock()
+
+	select {
+	case m.reload <- struct{}{}:
+	default:
+	}
+	return settings, nil
+}
+
+// Statuses returns the latest status of every connection, ordered by connection name
+func (m *HealthMonitor) Statuses() []*models.ClusterHealthStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]*models.ClusterHealthStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		copied := *status
+		statuses = append(statuses, &copied)
+	}
+	sortStatuses(statuses)
+	return statuses
+}
+
+// CheckAll checks every connection concurrently, records the results and emits a
+// change event for each connection whose status changed
+func (m *HealthMonitor) CheckAll(ctx context.Context) ([]*models.ClusterHealthStatus, error) {
+	m.checkMu.Lock()
+	defer m.checkMu.Unlock()
+
+	configs, err := m.configService.GetAllConfigs(nil)
+	if err != nil {
+		logging.Errorf("❌ Health monitor failed to list connections: %v", err)
+		return nil, fmt.Errorf("failed to get configurations: %w", err)
+	}
+
+	settings := m.Settings()
+	timeout := time.Duration(settings.TimeoutSeconds) * time.Second
+	semaphore := make(chan struct{}, settings.MaxConcurrent)
+
+	results := make([]*models.ClusterHealthStatus, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func(i int, config *models.Config) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				results[i] = &models.ClusterHealthStatus{
+					ConfigID:       config.ID,
+					ConnectionName: config.ConnectionName,
+					Status:         models.HealthStatusUnknown,
+				}
+				return
+			}
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i] = m.esService.CheckClusterHealth(checkCtx, config)
+		}(i, config)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		// Shutting down; don't record results cut short by the cancellation
+		return results, nil
+	}
+
+	recorded := m.record(configs, results)
+	for _, observer := range m.observers {
+		observer(ctx, recorded)
+	}
+	return recorded, nil
+}
+
+// record stores the results of a poll round, forgets deleted connections and emits
+// change events. It returns copies of the stored statuses.
+func (m *HealthMonitor) record(configs []*models.Config, results []*models.ClusterHealthStatus) []*models.ClusterHealthStatus {
+	var changed []*models.ClusterHealthStatus
+	recorded := make([]*models.ClusterHealthStatus, 0, len(results))
+
+	m.mu.Lock()
+	current := make(map[int]bool, len(configs))
+	for _, result := range results {
+		current[result.ConfigID] = true
+
+		previous, seen := m.statuses[result.ConfigID]
+		switch {
+		case !seen:
+			result.ChangedAt = result.CheckedAt
+		case previous.Status != result.Status:
+			result.PreviousStatus = previous.Status
+			result.ChangedAt = result.CheckedAt
+		default:
+			result.PreviousStatus = previous.PreviousStatus
+			result.ChangedAt = previous.ChangedAt
+		}
+		m.statuses[result.ConfigID] = result
+
+		copied := *result
+		recorded = append(recorded, &copied)
+		if !seen || previous.Status != result.Status {
+			changed = append(changed, &copied)
+		}
+	}
+	for id := range m.statuses {
+		if !current[id] {
+			delete(m.statuses, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, status := range changed {
+		if status.PreviousStatus != "" {
+			logging.Infof("🩺 %s health changed from %s to %s", status.ConnectionName, status.PreviousStatus, status.Status)
+		}
+		if m.emit != nil {
+			m.emit(models.HealthStatusChangedEvent, status)
+		}
+	}
+
+	sortStatuses(recorded)
+	return recorded
+}
+
+// sortStatuses orders statuses by connection name, then ID
+func sortStatuses(statuses []*models.ClusterHealthStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].ConnectionName != statuses[j].ConnectionName {
+			return statuses[i].ConnectionName < statuses[j].ConnectionName
+		}
+		return statuses[i].ConfigID < statuses[j].ConfigID
+	})
+}
+
+// CheckClusterHealth checks the health of a single connection within ctx. Failures to
+// reach the cluster are reported as "unreachable"; error responses as "error".
+func (s *ElasticsearchService) CheckClusterHealth(ctx context.Context, config *models.Config) *models.ClusterHealthStatus {
+	status := &models.ClusterHealthStatus{
+		ConfigID:       config.ID,
+		ConnectionName: config.ConnectionName,
+	}
+
+	connReq := config.ToConnectionRequest()
+	endpoint := "/_cluster/health"
+	if connReq.ClusterProfile != nil && !connReq.ClusterProfile.SupportsClusterHealth() {
+		endpoint = "/" // Serverless projects only tell us whether they answer
+	}
+
+	start := time.Now()
+	defer func() {
+		status.LatencyMs = time.Since(start).Milliseconds()
+		status.CheckedAt = time.Now().UTC().Format(time.RFC3339)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.buildURL(connReq, endpoint), nil)
+	if err != nil {
+		status.Status = models.HealthStatusError
+		status.ErrorCode = "REQUEST_CREATION_ERROR"
+		status.Error = err.Error()
+		return status
+	}
+	if err := s.addAuthentication(req, connReq); err != nil {
+		status.Status = models.HealthStatusError
+		status.ErrorCode = "AUTH_SETUP_ERROR"
+		status.Error = err.Error()
+		return status
+	}
+
+	resp, err := s.doRequest(req, connReq)
+	if err != nil {
+		status.Status = models.HealthStatusUnreachable
+		status.ErrorCode, _ = classifyConnectionError(err)
+		status.Error = err.Error()
+		return status
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		status.Status = models.HealthStatusUnreachable
+		status.ErrorCode, _ = classifyConnectionError(err)
+		status.Error = fmt.Sprintf("failed to read response: %v", err)
+		return status
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		status.Status = models.HealthStatusError
+		status.ErrorCode = "AUTH_ERROR"
+		status.Error = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return status
+	case resp.StatusCode != http.StatusOK:
+		status.Status = models.HealthStatusError
+		status.ErrorCode = "HTTP_ERROR"
+		status.Error = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return status
+	case endpoint == "/":
+		status.Status = models.HealthStatusAvailable
+		return status
+	}
+
+	var health models.ClusterHealth
+	if err := json.Unmarshal(body, &health); err != nil || health.Status == "" {
+		status.Status = models.HealthStatusError
+		status.ErrorCode = "PARSE_ERROR"
+		status.Error = "unexpected cluster health response"
+		return status
+	}
+
+	status.Status = health.Status
+	status.Health = &health
+	return status
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"elasticgaze/internal/logging"
+	"elasticgaze/internal/models"
+	"elasticgaze/internal/repository"
+)
+
+// historyPruneEvery is how many recorded requests pass between automatic prunes of the history
+const historyPruneEvery = 100
+
+// RequestRecorder receives every REST request executed through ExecuteRestRequest
+type RequestRecorder func(config *models.Config, req *models.ElasticsearchRestRequest, resp *models.ElasticsearchRestResponse)
+
+// HistoryService records executed REST requests and lets them be searched, replayed,
+// pinned and saved into collections
+type HistoryService struct {
+	repo               *repository.HistoryRepository
+	collectionsService *CollectionsService
+
+	mu       sync.Mutex
+	recorded int // Requests recorded since the last automatic prune
+}
+
+// NewHistoryService creates a new history service
+func NewHistoryService(repo *repository.HistoryRepository, collectionsService *CollectionsService) *HistoryService {
+	return &HistoryService{
+		repo:               repo,
+		collectionsService: collectionsService,
+	}
+}
+
+// Record stores an executed request. Failures are logged rather than returned, so a
+// full disk never fails the request itself. Every historyPruneEvery requests the oldest
+// unpinned entries beyond DefaultHistoryMaxEntries are removed.
+func (s *HistoryService) Record(config *models.Config, req *models.ElasticsearchRestRequest, resp *models.ElasticsearchRestResponse) {
+	entry := &models.HistoryEntry{
+		ConfigID:       config.ID,
+		ConnectionName: config.ConnectionName,
+		Method:         strings.ToUpper(strings.TrimSpace(req.Method)),
+		URL:            stripUserinfo(req.EndpointWithQuery()),
+		StatusCode:     resp.StatusCode,
+		Success:        resp.Success,
+		DurationMs:     resp.DurationMs,
+		ResponseSize:   len(resp.Response),
+	}
+	if req.Body != nil && strings.TrimSpace(*req.Body) != "" {
+		body := maskBodyCredentials(*req.Body)
+		entry.Body = &body
+	}
+	if req.Validate() == nil {
+		entry.Headers = historyHeaders(req.Headers) // A rejected request may carry an Authorization header
+	}
+	if resp.ErrorCode != "" {
+		entry.ErrorCode = &resp.ErrorCode
+	}
+	if resp.ErrorDetails != "" {
+		entry.ErrorDetails = &resp.ErrorDetails
+	}
+	entry.Response, entry.ResponseTruncated = truncateUTF8(resp.Response, models.HistoryResponseLimit)
+
+	if _, err := s.repo.Create(entry); err != nil {
+		logging.Warnf("⚠️ Failed to record %s %s in the request history: %v", entry.Method, entry.URL, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.recorded++
+	prune := s.recorded >= historyPruneEvery
+	if prune {
+		s.recorded = 0
+	}
+	s.mu.Unlock()
+
+	if prune {
+		if removed, err := s.repo.Prune(-1, models.DefaultHistoryMaxEntries, -1); err != nil {
+			logging.Warnf("⚠️ Failed to prune the request history: %v", err)
+		} else if removed > 0 {
+			logging.Infof("🧹 Pruned %d old request history entries", removed)
+		}
+	}
+}
+
+// credentialHeaderWords mark header names whose values are likely credentials
+var credentialHeaderWords = []string{"auth", "token", "key", "secret", "password", "cookie", "session", "credential", "signature"}
+
+// historyHeaders returns the headers without the ones that look like credentials, such as
+// X-Api-Key or Cookie, so the history database never holds them in plaintext
+func historyHeaders(headers []models.HTTPHeader) []models.HTTPHeader {
+	var kept []models.HTTPHeader
+	for _, header := range headers {
+		if !isCredentialHeader(header.Name) {
+			kept = append(kept, header)
+		}
+	}
+	return kept
+}
+
+// isCredentialHeader reports whether a header name suggests its value is a credential
+func isCredentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range credentialHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// credentialFieldPattern matches JSON string fields that hold credentials, such as the
+// password of PUT _security/user/x or the secrets of a repository or connector, in JSON
+// and NDJSON bodies alike. Only string values are matched; the key must be unescaped.
+var credentialFieldPattern = regexp.MustCompile(`(?i)("(?:[^"\\]*(?:password|passwd|secret|api_?key|private_key|_token)[^"\\]*|token)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// maskBodyCredentials replaces the values of credential fields in a request body with
+// RedactedSecret, keeping the rest of the body as it was typed
+func maskBodyCredentials(body string) string {
+	return credentialFieldPattern.ReplaceAllString(body, `${1}"`+models.RedactedSecret+`"`)
+}
+
+// stripUserinfo removes user:password@ from a full URL. Relative endpoints are returned unchanged.
+func stripUserinfo(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err == nil {
+		if u.Scheme == "" || u.Host == "" || u.User == nil {
+			return endpoint
+		}
+		u.User = nil
+		return u.String()
+	}
+
+	// A password with an unescaped "/" or "#" does not parse; drop everything up to the
+	// last "@" before the query instead
+	scheme, rest, ok := strings.Cut(endpoint, "://")
+	if !ok || strings.ContainsAny(scheme, "/?") {
+		return endpoint
+	}
+	authority, _, _ := strings.Cut(rest, "?")
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		return scheme + "://" + rest[at+1:]
+	}
+	return endpoint
+}
+
+// truncateUTF8 cuts s to at most limit bytes without splitting a character
+func truncateUTF8(s string, limit int) (string, bool) {
+	if len(s) <= limit {
+		return s, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
+
+// GetHistory returns the history entries matching the filter, newest first
+func (s *HistoryService) GetHistory(filter *models.HistoryFilter) ([]*models.HistoryEntry, error) {
+	if filter != nil {
+		if err := filter.Validate(); err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+	}
+	return s.repo.GetAll(filter)
+}
+
+// GetHistoryEntry returns a single history entry
+func (s *HistoryService) GetHistoryEntry(id int) (*models.HistoryEntry, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid history entry ID")
+	}
+	return s.repo.GetByID(id)
+}
+
+// ReplayRequest rebuilds the REST request of a history entry, to run it again on the
+// same connection
+func (s *HistoryService) ReplayRequest(id int) (*models.ElasticsearchRestRequest, error) {
+	entry, err := s.GetHistoryEntry(id)
+	if err != nil {
+		return nil, err
+	}
+
+	configID := entry.ConfigID
+	return &models.ElasticsearchRestRequest{
+		Method:   entry.Method,
+		Endpoint: entry.URL,
+		Headers:  entry.Headers,
+		Body:     entry.Body,
+		ConfigID: &configID,
+	}, nil
+}
+
+// PinHistoryEntry pins or unpins a history entry; pinned entries are never pruned
+func (s *HistoryService) PinHistoryEntry(id int, pinned bool) (*models.HistoryEntry, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid history entry ID")
+	}
+	return s.repo.SetPinned(id, pinned)
+}
+
+// DeleteHistoryEntry removes a single history entry
+func (s *HistoryService) DeleteHistoryEntry(id int) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid history entry ID")
+	}
+	return s.repo.Delete(id)
+}
+
+// SaveToCollection saves a history entry as a request in a collection
+func (s *HistoryService) SaveToCollection(req *models.SaveHistoryRequest) (*models.Request, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	entry, err := s.repo.GetByID(req.HistoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = entry.Method + " " + entry.URL
+		if truncated, ok := truncateUTF8(name, 255); ok {
+			name = truncated
+		}
+	}
+
+	return s.collectionsService.CreateRequest(&models.CreateRequestRequest{
+		Name:         name,
+		Method:       entry.Method,
+		URL:          entry.URL,
+		Body:         entry.Body,
+		Description:  req.Description,
+		FolderID:     req.FolderID,
+		CollectionID: req.CollectionID,
+	})
+}
+
+// PruneHistory removes unpinned entries by age, count or size and returns how many were removed
+func (s *HistoryService) PruneHistory(req *models.PruneHistoryRequest) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, fmt.Errorf("validation error: %w", err)
+	}
+
+	olderThanDays, maxEntries, maxBytes := -1, -1, int64(-1)
+	if req.OlderThanDays != nil {
+		olderThanDays = *req.OlderThanDays
+	}
+	if req.MaxEntries != nil {
+		maxEntries = *req.MaxEntries
+	}
+	if req.MaxSizeMB != nil {
+		maxBytes = int64(*req.MaxSizeMB) << 20
+	}
+
+	removed, err := s.repo.Prune(olderThanDays, maxEntries, maxBytes)
+	if err != nil {
+		return 0, err
+	}
+	logging.Infof("🧹 Pruned %d request history entries", removed)
+	return removed, nil
+}